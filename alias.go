@@ -0,0 +1,224 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// parseAliases parses name=Alias pairs renaming the Go identifiers generated
+// for ABI methods and events.
+func parseAliases(pairs []string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for _, pair := range pairs {
+		name, alias, ok := strings.Cut(pair, "=")
+		name, alias = strings.TrimSpace(name), strings.TrimSpace(alias)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("alias: %q is not of the form name=Alias", pair)
+		}
+
+		if !token.IsIdentifier(alias) || !token.IsExported(alias) {
+			return nil, fmt.Errorf("alias: %q is not an exported Go identifier", alias)
+		}
+
+		if _, ok := aliases[name]; ok {
+			return nil, fmt.Errorf("alias: %s is aliased more than once", name)
+		}
+		aliases[name] = alias
+	}
+
+	return aliases, nil
+}
+
+// goName returns the Go identifier of the ABI method or event name, applying
+// its alias if any.
+func goName(name string, aliases map[string]string) string {
+	if alias, ok := aliases[name]; ok {
+		return alias
+	}
+
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// identFunc returns the template function naming the exported identifiers
+// of the bindings, lower-casing their first letter when unexported is set.
+func identFunc(unexported bool) func(string) string {
+	return func(name string) string {
+		if !unexported {
+			return name
+		}
+
+		r, n := utf8.DecodeRuneInString(name)
+		return string(unicode.ToLower(r)) + name[n:]
+	}
+}
+
+// checkAliases reports aliases naming no method or event of the ABI.
+func checkAliases(aliases map[string]string, known map[string]bool) error {
+	var unknown []string
+	for name := range aliases {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("alias: no method or event named %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// checkIdentifiers reports Go identifiers that the bindings would declare
+// more than once, either between methods and events or with the helpers
+// generated alongside them. reserved maps the identifiers declared by the
+// optional outputs to their owners.
+func checkIdentifiers(data TemplateData, reserved map[string]string) error {
+	ident := identFunc(data.Unexported)
+	exported := map[string]string{
+		"ABI":              "the ABI variable",
+		"Bin":              "the Bin variable",
+		"CodeReader":       "the CodeReader interface",
+		"CodeReport":       "the CodeReport type",
+		"VerifyDeployed":   "the VerifyDeployed helper",
+		"RawCall":          "the RawCall helper",
+		"MetaData":         "the MetaData variable",
+		"ContractMetaData": "the ContractMetaData type",
+		"WarpTime":         "the WarpTime helper",
+		"MineBlocks":       "the MineBlocks helper",
+		"ContractAddress":  "the ContractAddress variable",
+		"Prank":            "the Prank helper",
+		"StopPrank":        "the StopPrank helper",
+		"SetBalance":       "the SetBalance helper",
+		"SetStorageAt":     "the SetStorageAt helper",
+		"SetCode":          "the SetCode helper",
+		"Selector":         "the Selector helper",
+		"EventTopic":       "the EventTopic helper",
+		"ToWei":            "the ToWei helper",
+		"FromWei":          "the FromWei helper",
+		"ParseEther":       "the ParseEther helper",
+	}
+	helpers := []string{"methodError", "exec", "block", "blockMu", "chain", "chainState", "runtimeCode", "parsedABI", "binOnce", "binCode", "binGzip", "callResults", "packCall", "convertResult", "result", "stripMetadata", "decimalsOnce", "decimalsValue", "tokenDecimals", "handler", "httpArgs", "httpError", "httpRecover", "httpReply", "httpValue", "parseBig", "parseAddress", "parseFixedBytes", "parseTime"}
+	if data.CodecOnly {
+		exported = map[string]string{
+			"ABI":        "the ABI variable",
+			"Selector":   "the Selector helper",
+			"EventTopic": "the EventTopic helper",
+		}
+		helpers = []string{"codec", "methodError", "packInput", "unpackOutput", "convertResult", "result", "unpackEvent"}
+		if data.EventHandler != "" {
+			exported[data.EventHandler] = "the " + data.EventHandler + " interface"
+			exported["DispatchLog"] = "the DispatchLog function"
+		}
+	}
+	for _, name := range []string{"ErrDecode", "CallError"} {
+		exported[name] = "the " + name + " error"
+	}
+	if !data.CodecOnly {
+		exported["ErrNoCode"] = "the ErrNoCode error"
+		exported["ErrExecutionReverted"] = "the ErrExecutionReverted error"
+	}
+	for _, name := range []string{"FunctionPointer", "SplitFunctionPointer", "AddressTopic", "IntTopic", "BoolTopic", "FixedBytesTopic", "DynamicTopic", "AddressFromTopic", "UintFromTopic", "IntFromTopic", "BoolFromTopic", "FixedBytesFromTopic"} {
+		exported[name] = "the " + name + " helper"
+	}
+	if data.GoVersion >= 18 && !data.CodecOnly {
+		exported["Call"] = "the Call helper"
+	}
+	if data.Decimals != "" {
+		exported["FormatAmount"] = "the FormatAmount helper"
+		exported["ParseAmount"] = "the ParseAmount helper"
+	}
+
+	owners := make(map[string]string)
+	for name, owner := range exported {
+		owners[ident(name)] = owner
+	}
+	for name, owner := range reserved {
+		owners[name] = owner
+	}
+
+	if data.Unexported {
+		// Unexported names share the namespace of the generated helpers
+		// and must neither be keywords nor shadow predeclared identifiers
+		// the generated code relies on.
+		for _, name := range helpers {
+			owners[name] = "a generated helper"
+		}
+		for _, tm := range data.TypeMaps {
+			owners["fromBig"+tm.Shim] = "a generated helper"
+			owners["toBig"+tm.Shim] = "a generated helper"
+		}
+	}
+
+	declare := func(name, owner string) error {
+		name = ident(name)
+		if token.IsKeyword(name) || (data.Unexported && types.Universe.Lookup(name) != nil) {
+			return fmt.Errorf("%s of %s is a reserved Go identifier; use --alias to rename it", name, owner)
+		}
+
+		if prev, ok := owners[name]; ok {
+			return fmt.Errorf("%s of %s collides with %s; use --alias to rename it", name, owner, prev)
+		}
+
+		owners[name] = owner
+		return nil
+	}
+
+	for _, fn := range data.Funcs {
+		owner := "method " + fn.Method
+		if data.CodecOnly {
+			if err := declare("Pack"+fn.Name, owner); err != nil {
+				return err
+			}
+			if err := declare("Unpack"+fn.Name+"Output", owner); err != nil {
+				return err
+			}
+		} else if err := declare(fn.Name, owner); err != nil {
+			return err
+		}
+		if data.Calls {
+			if err := declare(fn.Name+"Call", owner); err != nil {
+				return err
+			}
+		}
+		if err := declare("Selector"+fn.Name, owner); err != nil {
+			return err
+		}
+	}
+
+	for _, event := range data.Events {
+		if err := declare("Topic"+event.Name, "event "+event.Sig); err != nil {
+			return err
+		}
+		if data.CodecOnly {
+			if err := declare("Unpack"+event.Name, "event "+event.Sig); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
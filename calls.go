@@ -0,0 +1,80 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fieldName returns the exported struct field holding the i-th input.
+func fieldName(in Argument, i int) string {
+	name := argName(in, i)
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// callType returns the <Method>Call type of fn, which holds the arguments
+// of a call so that it can be built, packed and executed separately. With
+// codec the calls are only packed.
+func callType(fn Function, ident func(string) string, codec bool) string {
+	name := ident(fn.Name + "Call")
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "// %s is a call of %s built ahead of its execution.\n", name, fn.Sig)
+	fmt.Fprintf(&s, "type %s struct {\n", name)
+	for i, in := range fn.Inputs {
+		fmt.Fprintf(&s, "\t%s %s\n", fieldName(in, i), argType(in))
+	}
+	s.WriteString("}\n\n")
+
+	var convert, pack, execConvert string
+	for i, in := range fn.Inputs {
+		in.Name = "c." + fieldName(in, i)
+		convert += mapArg(in, i, fmt.Sprintf("return nil, methodError(%q, err)", fn.Method))
+		execConvert += mapArg(in, i, fmt.Sprintf("err = methodError(%q, err)\n\t\treturn", fn.Method))
+		pack += packArg(in, i)
+	}
+
+	fmt.Fprintf(&s, "// Calldata packs the call.\n")
+	fmt.Fprintf(&s, "func (c %s) Calldata() ([]byte, error) {\n", name)
+	if codec {
+		fmt.Fprintf(&s, "%s\treturn packInput(%q%s)\n}\n", convert, fn.Method, pack)
+		return s.String()
+	}
+	fmt.Fprintf(&s, "%s\treturn packCall(%q%s)\n}\n\n", convert, fn.Method, pack)
+
+	var types, rets []string
+	for i, out := range fn.Outputs {
+		types = append(types, bindType(out.Type))
+		rets = append(rets, fmt.Sprintf("r%d %s", i, argType(out)))
+	}
+
+	fmt.Fprintf(&s, "// Execute executes the call inside evm.\n")
+	fmt.Fprintf(&s, "func (c %s) Execute() (%s) {\n", name, strings.Join(append(rets, "err error"), ", "))
+	s.WriteString(execConvert)
+	if len(fn.Outputs) == 0 {
+		fmt.Fprintf(&s, "\t_, err = callResults(%q%s)\n\treturn\n}\n", fn.Method, pack)
+		return s.String()
+	}
+
+	fmt.Fprintf(&s, "\tvalues, err := callResults(%q%s)\n\tif err != nil {\n\t\treturn\n\t}\n", fn.Method, pack)
+	s.WriteString(unpackResults(fn.Outputs, types, fmt.Sprintf("methodError(%q, err)", fn.Method)))
+	s.WriteString("\treturn\n}\n")
+	return s.String()
+}
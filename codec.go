@@ -0,0 +1,163 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// eventType returns the Go type an event input is decoded into. Indexed
+// inputs of dynamic types only carry the hash of their value.
+func eventType(in Argument) string {
+	switch in.Type.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy:
+		if in.Indexed {
+			return "common.Hash"
+		}
+	}
+
+	return argType(in)
+}
+
+// unpackResults returns the statements storing values into the named results
+// r0, r1, ..., converting the ones bound with --type-map. Errors are replaced
+// by the expression wrap, which wraps err.
+func unpackResults(args []Argument, types []string, wrap string) string {
+	fail := fmt.Sprintf("\t\terr = %s\n\t\treturn\n", wrap)
+
+	var s strings.Builder
+	for i, arg := range args {
+		if arg.Map != nil {
+			fmt.Fprintf(&s, "\tv%d, err := result[*big.Int](values, %d)\n\tif err != nil {\n%s\t}\n", i, i, fail)
+			fmt.Fprintf(&s, "\tif r%d, err = fromBig%s(v%d); err != nil {\n%s\t}\n", i, arg.Map.Shim, i, fail)
+			continue
+		}
+
+		fmt.Fprintf(&s, "\tif r%d, err = result[%s](values, %d); err != nil {\n%s\t}\n", i, types[i], i, fail)
+	}
+
+	return s.String()
+}
+
+// codecFunc returns the Pack and Unpack functions of fn.
+func codecFunc(fn Function, ident func(string) string) string {
+	var convert, pack string
+	for i, in := range fn.Inputs {
+		in.Name = paramName(in, i)
+		convert += mapArg(in, i, fmt.Sprintf("return nil, methodError(%q, err)", fn.Method))
+		pack += packArg(in, i)
+	}
+
+	var types []string
+	for _, out := range fn.Outputs {
+		types = append(types, bindType(out.Type))
+	}
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "// %s%s packs the calldata of %s.\n", ident("Pack"), fn.Name, fn.Sig)
+	s.WriteString(docLines("", fn.Doc))
+	fmt.Fprintf(&s, "func %s%s(%s) ([]byte, error) {\n", ident("Pack"), fn.Name, parseIn(fn.Inputs))
+	fmt.Fprintf(&s, "%s\treturn packInput(%q%s)\n}\n\n", convert, fn.Method, pack)
+
+	var rets []string
+	for i, out := range fn.Outputs {
+		rets = append(rets, fmt.Sprintf("r%d %s", i, argType(out)))
+	}
+
+	fmt.Fprintf(&s, "// %s%sOutput unpacks the return data of %s.\n", ident("Unpack"), fn.Name, fn.Sig)
+	fmt.Fprintf(&s, "func %s%sOutput(data []byte) (%s) {\n", ident("Unpack"), fn.Name, strings.Join(append(rets, "err error"), ", "))
+	if len(fn.Outputs) == 0 {
+		fmt.Fprintf(&s, "\t_, err = unpackOutput(%q, data)\n\treturn\n}\n", fn.Method)
+		return s.String()
+	}
+
+	fmt.Fprintf(&s, "\tvalues, err := unpackOutput(%q, data)\n\tif err != nil {\n\t\treturn\n\t}\n", fn.Method)
+	s.WriteString(unpackResults(fn.Outputs, types, fmt.Sprintf("methodError(%q, err)", fn.Method)))
+	s.WriteString("\treturn\n}\n")
+	return s.String()
+}
+
+// codecEvent returns the Unpack function of event.
+func codecEvent(event Event, ident func(string) string) string {
+	var types, rets []string
+	for i, in := range event.Inputs {
+		types = append(types, eventType(in))
+		rets = append(rets, fmt.Sprintf("r%d %s", i, types[i]))
+	}
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "// %s%s unpacks a %s log from its topics and data.\n", ident("Unpack"), event.Name, event.Sig)
+	s.WriteString(docLines("", event.Doc))
+	fmt.Fprintf(&s, "func %s%s(topics []common.Hash, data []byte) (%s) {\n", ident("Unpack"), event.Name, strings.Join(append(rets, "err error"), ", "))
+	if len(event.Inputs) == 0 {
+		fmt.Fprintf(&s, "\t_, err = unpackEvent(%q, topics, data)\n\treturn\n}\n", event.ABIName)
+		return s.String()
+	}
+
+	fmt.Fprintf(&s, "\tvalues, err := unpackEvent(%q, topics, data)\n\tif err != nil {\n\t\treturn\n\t}\n", event.ABIName)
+	s.WriteString(unpackResults(event.Inputs, types, fmt.Sprintf("fmt.Errorf(\"%s event: %%w\", err)", event.ABIName)))
+	s.WriteString("\treturn\n}\n")
+	return s.String()
+}
+
+// codecDispatch returns the handler interface, with a method per event, and
+// the DispatchLog function routing logs to it by their first topic. Anonymous
+// events have no topic to be routed by and are left out.
+func codecDispatch(handler string, events []Event, ident func(string) string) string {
+	var methods, cases strings.Builder
+	for _, event := range events {
+		if event.Anonymous {
+			continue
+		}
+
+		var params, args []string
+		for i, in := range event.Inputs {
+			params = append(params, fmt.Sprintf("%s %s", paramName(in, i), eventType(in)))
+			args = append(args, fmt.Sprintf("r%d", i))
+		}
+
+		fmt.Fprintf(&methods, "\t// %s handles a %s log.\n", event.Name, event.Sig)
+		fmt.Fprintf(&methods, "\t%s(%s) error\n", event.Name, strings.Join(params, ", "))
+
+		unpack := fmt.Sprintf("%s%s(log.Topics, log.Data)", ident("Unpack"), event.Name)
+		fmt.Fprintf(&cases, "\tcase %s%s:\n", ident("Topic"), event.Name)
+		if len(args) == 0 {
+			fmt.Fprintf(&cases, "\t\tif err := %s; err != nil {\n\t\t\treturn err\n\t\t}\n", unpack)
+		} else {
+			fmt.Fprintf(&cases, "\t\t%s, err := %s\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n", strings.Join(args, ", "), unpack)
+		}
+		fmt.Fprintf(&cases, "\t\treturn h.%s(%s)\n", event.Name, strings.Join(args, ", "))
+	}
+
+	name, dispatch, errDecode := ident(handler), ident("DispatchLog"), ident("ErrDecode")
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "// %s handles the events of the contract routed by %s.\n", name, dispatch)
+	fmt.Fprintf(&s, "type %s interface {\n%s}\n\n", name, methods.String())
+	fmt.Fprintf(&s, "// %s unpacks log and passes it to the method of h handling its event,\n", dispatch)
+	fmt.Fprintf(&s, "// returning the error of the method. Logs of other events fail with %s.\n", errDecode)
+	fmt.Fprintf(&s, "func %s(log types.Log, h %s) error {\n", dispatch, name)
+	fmt.Fprintf(&s, "\tif len(log.Topics) == 0 {\n\t\treturn fmt.Errorf(\"%%w: log has no topics\", %s)\n\t}\n\n", errDecode)
+	fmt.Fprintf(&s, "\tswitch log.Topics[0] {\n%s\t}\n\n", cases.String())
+	fmt.Fprintf(&s, "\treturn fmt.Errorf(\"%%w: log has the unknown topic %%s\", %s, log.Topics[0])\n}\n", errDecode)
+	return s.String()
+}
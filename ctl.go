@@ -0,0 +1,233 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// checkCLI reports an error if the command line tool cannot be generated for
+// the bindings of data.
+func checkCLI(data TemplateData) error {
+	if data.Unexported {
+		return fmt.Errorf("cli: the command line tool cannot call unexported bindings")
+	}
+
+	return checkArgs("cli", data.Funcs)
+}
+
+// writeCLI generates a main package under out/cmd/<pkg>ctl exposing every
+// function of the bindings as a subcommand. The bindings must pass checkCLI.
+func writeCLI(out string, data TemplateData, hdr fileHeader) error {
+	imp, err := importPath(out)
+	if err != nil {
+		return err
+	}
+
+	fnMap := map[string]any{
+		"cliFlag": argName,
+		"cliParse": func(in Argument, i int) string {
+			return parseArg(in, i, fmt.Sprintf("*flag%d", i), "fatal(err)", "fatal(err)")
+		},
+		"cliCall": cliCall,
+	}
+
+	templ := template.Must(template.New("").Funcs(fnMap).Parse(TemplCLI))
+
+	var b bytes.Buffer
+	err = templ.Execute(&b, CLIData{
+		Package: data.Package,
+		Import:  imp,
+		Funcs:   data.Funcs,
+	})
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(out, "cmd", data.Package+"ctl")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	return writeGo(filepath.Join(dir, "main.go"), b.Bytes(), hdr)
+}
+
+// importPath resolves the import path of dir from the nearest go.mod above it.
+func importPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	re := regexp.MustCompile(`(?m)^module\s+"?([^"\s]+)"?`)
+	for d := abs; ; d = filepath.Dir(d) {
+		src, err := ioutil.ReadFile(filepath.Join(d, "go.mod"))
+		if err == nil {
+			parts := re.FindSubmatch(src)
+			if parts == nil {
+				return "", fmt.Errorf("cli: no module directive in %s", filepath.Join(d, "go.mod"))
+			}
+
+			rel, err := filepath.Rel(d, abs)
+			if err != nil {
+				return "", err
+			}
+
+			return path.Join(string(parts[1]), filepath.ToSlash(rel)), nil
+		}
+
+		if filepath.Dir(d) == d {
+			return "", fmt.Errorf("cli: no go.mod found above %s", dir)
+		}
+	}
+}
+
+// checkArgs reports an error if any input of funcs cannot be parsed from a
+// string.
+func checkArgs(target string, funcs []Function) error {
+	for _, fn := range funcs {
+		for _, in := range fn.Inputs {
+			if argParser(in.Type, "s") == "" {
+				return fmt.Errorf("%s: unsupported input type %s in %s", target, in.Type, fn.Method)
+			}
+		}
+
+		// The command line tool lives in its own package and cannot set
+		// the conversions of types bound with --type-map.
+		for _, arg := range append(append([]Argument{}, fn.Inputs...), fn.Outputs...) {
+			if target == "cli" && arg.Map != nil {
+				return fmt.Errorf("%s: %s binds %s with --type-map", target, fn.Method, arg.Map.Type)
+			}
+		}
+	}
+
+	return nil
+}
+
+// argName returns the name of the i-th input, falling back to argN for
+// unnamed inputs.
+func argName(in Argument, i int) string {
+	if in.Name == "" {
+		return fmt.Sprintf("arg%d", i)
+	}
+
+	return in.Name
+}
+
+// argParser returns the expression parsing the string expression s into the
+// given type, or an empty string if the type cannot be read from the command
+// line.
+func argParser(kind abi.Type, s string) string {
+	switch kind.T {
+	case abi.AddressTy:
+		return fmt.Sprintf("parseAddress(%s)", s)
+	case abi.IntTy, abi.UintTy:
+		if bindType(kind) == "*big.Int" {
+			return fmt.Sprintf("parseBig(%s)", s)
+		}
+
+		if kind.T == abi.UintTy {
+			return fmt.Sprintf("strconv.ParseUint(%s, 0, %d)", s, kind.Size)
+		}
+		return fmt.Sprintf("strconv.ParseInt(%s, 0, %d)", s, kind.Size)
+	case abi.BoolTy:
+		return fmt.Sprintf("strconv.ParseBool(%s)", s)
+	case abi.StringTy:
+		return fmt.Sprintf("%s, error(nil)", s)
+	case abi.BytesTy:
+		return fmt.Sprintf("hexutil.Decode(%s)", s)
+	case abi.FixedBytesTy, abi.FunctionTy:
+		return fmt.Sprintf("parseFixedBytes(%s, %d)", s, kind.Size)
+	default:
+		return ""
+	}
+}
+
+// parseArg returns the statements declaring argN from the string expression
+// src, running fail when it cannot be parsed and broken when its --type-map
+// conversion is not set.
+func parseArg(in Argument, i int, src, fail, broken string) string {
+	parser := argParser(in.Type, src)
+	if in.Time {
+		parser = fmt.Sprintf("parseTime(%s)", src)
+	}
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "\t\tv%d, err := %s\n\t\tif err != nil {\n\t\t\t%s\n\t\t}\n", i, parser, fail)
+
+	switch in.Type.T {
+	case abi.IntTy, abi.UintTy:
+		if in.Map != nil {
+			fmt.Fprintf(&s, "\t\targ%d, err := fromBig%s(v%d)\n\t\tif err != nil {\n\t\t\t%s\n\t\t}\n", i, in.Map.Shim, i, broken)
+			return s.String()
+		}
+
+		if bind := bindType(in.Type); !in.Time && bind != "*big.Int" {
+			fmt.Fprintf(&s, "\t\targ%d := %s(v%d)\n", i, bind, i)
+			return s.String()
+		}
+	case abi.FixedBytesTy, abi.FunctionTy:
+		fmt.Fprintf(&s, "\t\tvar arg%d %s\n\t\tcopy(arg%d[:], v%d)\n", i, bindType(in.Type), i, i)
+		return s.String()
+	}
+
+	fmt.Fprintf(&s, "\t\targ%d := v%d\n", i, i)
+	return s.String()
+}
+
+func cliCall(pkg string, fn Function) string {
+	var args, rets []string
+	for i := range fn.Inputs {
+		args = append(args, fmt.Sprintf("arg%d", i))
+	}
+
+	for i := range fn.Outputs {
+		rets = append(rets, fmt.Sprintf("r%d", i))
+	}
+
+	call := fmt.Sprintf("%s.%s(%s)", pkg, fn.Name, strings.Join(args, ", "))
+	if len(rets) == 0 {
+		return fmt.Sprintf("if err := %s; err != nil {\n\t\t\tfatal(err)\n\t\t}", call)
+	}
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "%s, err := %s\n\t\tif err != nil {\n\t\t\tfatal(err)\n\t\t}", strings.Join(rets, ", "), call)
+	for i, out := range fn.Outputs {
+		switch out.Type.T {
+		case abi.BytesTy:
+			fmt.Fprintf(&s, "\n\t\tfmt.Println(hexutil.Encode(r%d))", i)
+		case abi.FixedBytesTy, abi.FunctionTy:
+			fmt.Fprintf(&s, "\n\t\tfmt.Println(hexutil.Encode(r%d[:]))", i)
+		default:
+			fmt.Fprintf(&s, "\n\t\tfmt.Println(r%d)", i)
+		}
+	}
+
+	return s.String()
+}
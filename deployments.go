@@ -0,0 +1,232 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// hardhatExport is a network entry of a hardhat-deploy export.
+type hardhatExport struct {
+	Name      string `json:"name"`
+	ChainID   string `json:"chainId"`
+	Contracts map[string]struct {
+		Address string `json:"address"`
+	} `json:"contracts"`
+}
+
+// readDeployments reads the addresses of contract from either a manifest
+// mapping chain IDs to contract names to addresses, or a hardhat-deploy
+// export as written by --export or --export-all.
+func readDeployments(path, contract string) ([]Deployment, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest map[string]map[string]string
+	if err := json.Unmarshal(src, &manifest); err == nil {
+		var deployments []Deployment
+		for chain, contracts := range manifest {
+			addr, ok := contracts[contract]
+			if !ok {
+				continue
+			}
+
+			d, err := newDeployment(chain, addr)
+			if err != nil {
+				return nil, fmt.Errorf("deployments: %v", err)
+			}
+
+			deployments = append(deployments, d)
+		}
+
+		return deployments, nil
+	}
+
+	var exports []hardhatExport
+	var all map[string][]hardhatExport
+	var single hardhatExport
+	switch {
+	case json.Unmarshal(src, &all) == nil:
+		for _, networks := range all {
+			exports = append(exports, networks...)
+		}
+	case json.Unmarshal(src, &single) == nil && single.ChainID != "":
+		exports = append(exports, single)
+	default:
+		return nil, fmt.Errorf("deployments: %s is neither a manifest nor a hardhat-deploy export", path)
+	}
+
+	var deployments []Deployment
+	for _, network := range exports {
+		c, ok := network.Contracts[contract]
+		if !ok {
+			continue
+		}
+
+		d, err := newDeployment(network.ChainID, c.Address)
+		if err != nil {
+			return nil, fmt.Errorf("deployments: %v", err)
+		}
+
+		d.Network = network.Name
+		deployments = append(deployments, d)
+	}
+
+	return deployments, nil
+}
+
+// foundryBroadcast is the run log written by forge script --broadcast.
+type foundryBroadcast struct {
+	Chain        uint64 `json:"chain"`
+	Transactions []struct {
+		Hash            string `json:"hash"`
+		TransactionType string `json:"transactionType"`
+		ContractName    string `json:"contractName"`
+		ContractAddress string `json:"contractAddress"`
+	} `json:"transactions"`
+	Receipts []struct {
+		TransactionHash string `json:"transactionHash"`
+		BlockNumber     string `json:"blockNumber"`
+	} `json:"receipts"`
+}
+
+// readBroadcast reads the latest deployment of contract from a forge script
+// broadcast log such as broadcast/<script>/<chainid>/run-latest.json.
+func readBroadcast(path, contract string) ([]Deployment, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var run foundryBroadcast
+	if err := json.Unmarshal(src, &run); err != nil {
+		return nil, err
+	}
+
+	blocks := make(map[string]uint64)
+	for _, r := range run.Receipts {
+		n, err := hexutil.DecodeUint64(r.BlockNumber)
+		if err != nil {
+			return nil, fmt.Errorf("broadcast: invalid block number %q in %s", r.BlockNumber, path)
+		}
+		blocks[strings.ToLower(r.TransactionHash)] = n
+	}
+
+	for i := len(run.Transactions) - 1; i >= 0; i-- {
+		tx := run.Transactions[i]
+		if tx.ContractName != contract || (tx.TransactionType != "CREATE" && tx.TransactionType != "CREATE2") {
+			continue
+		}
+
+		d, err := newDeployment(strconv.FormatUint(run.Chain, 10), tx.ContractAddress)
+		if err != nil {
+			return nil, fmt.Errorf("broadcast: %v", err)
+		}
+
+		d.TxHash = common.HexToHash(tx.Hash).Hex()
+		d.Block = blocks[strings.ToLower(tx.Hash)]
+		return []Deployment{d}, nil
+	}
+
+	return nil, nil
+}
+
+func newDeployment(chain, addr string) (Deployment, error) {
+	id, err := strconv.ParseUint(chain, 10, 64)
+	if err != nil {
+		return Deployment{}, fmt.Errorf("invalid chain id %q", chain)
+	}
+
+	if !common.IsHexAddress(addr) {
+		return Deployment{}, fmt.Errorf("invalid address %q on chain %s", addr, chain)
+	}
+
+	return Deployment{ChainID: id, Address: common.HexToAddress(addr).Hex()}, nil
+}
+
+// addressesIdents lists the identifiers addresses.go declares, before
+// --unexported applies.
+var addressesIdents = []string{"Addresses", "AddressForChain", "Deployments", "DeploymentInfo"}
+
+// writeAddresses generates addresses.go in the bindings package with the
+// deployed addresses of contract per chain, unexported if unexported is set.
+func writeAddresses(out, pkg, contract string, deployments []Deployment, unexported bool, hdr fileHeader) error {
+	if len(deployments) == 0 {
+		return fmt.Errorf("deployments: no deployments of %s found", contract)
+	}
+
+	sort.Slice(deployments, func(i, j int) bool {
+		if deployments[i].ChainID != deployments[j].ChainID {
+			return deployments[i].ChainID < deployments[j].ChainID
+		}
+		return deployments[i].Network < deployments[j].Network
+	})
+
+	// Several records may describe the same chain, such as the hardhat and
+	// localhost networks, or a manifest next to a broadcast log.
+	var unique []Deployment
+	var metadata bool
+	for _, d := range deployments {
+		metadata = metadata || d.TxHash != ""
+		if n := len(unique); n > 0 && unique[n-1].ChainID == d.ChainID {
+			if unique[n-1].Address != d.Address {
+				return fmt.Errorf("deployments: %s has different addresses on chain %d", contract, d.ChainID)
+			}
+
+			if unique[n-1].TxHash == "" {
+				d.Network = unique[n-1].Network
+				unique[n-1] = d
+			}
+			continue
+		}
+
+		unique = append(unique, d)
+	}
+	deployments = unique
+
+	fnMap := map[string]any{"ident": identFunc(unexported)}
+	templ := template.Must(template.New("").Funcs(fnMap).Parse(TemplAddresses))
+
+	var b bytes.Buffer
+	err := templ.Execute(&b, AddressesData{
+		Package:     pkg,
+		Unexported:  unexported,
+		Contract:    contract,
+		Deployments: deployments,
+		Metadata:    metadata,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeGo(filepath.Join(out, "addresses.go"), b.Bytes(), hdr)
+}
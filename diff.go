@@ -0,0 +1,241 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/urfave/cli/v2"
+)
+
+// abiEntry is a function, event or error of an ABI.
+type abiEntry struct {
+	kind   string
+	name   string
+	sig    string
+	id     string
+	detail string
+	// mutability is the state mutability of functions.
+	mutability string
+}
+
+// abiChange is a difference between two ABIs.
+type abiChange struct {
+	entry    abiEntry
+	verb     string
+	note     string
+	breaking bool
+}
+
+func (c abiChange) String() string {
+	s := fmt.Sprintf("%-8s %s %s", c.verb, c.entry.kind, c.entry.sig)
+	if c.entry.id != "" {
+		s += " " + c.entry.id
+	}
+
+	if c.note != "" {
+		s += ": " + c.note
+	}
+
+	if c.breaking {
+		s += " (breaking)"
+	}
+
+	return s
+}
+
+// differ prints the differences between two ABI files and fails when any of
+// them breaks existing callers.
+func differ(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return fmt.Errorf("diff: expected the old and new ABI paths")
+	}
+
+	var vecs [2]abi.ABI
+	for i := range vecs {
+		f, err := os.Open(ctx.Args().Get(i))
+		if err != nil {
+			return err
+		}
+
+		vecs[i], err = abi.JSON(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	breaking := 0
+	for _, c := range diffABI(vecs[0], vecs[1]) {
+		fmt.Println(c)
+		if c.breaking {
+			breaking++
+		}
+	}
+
+	if breaking > 0 {
+		return cli.Exit(fmt.Sprintf("%d breaking change(s)", breaking), 1)
+	}
+
+	return nil
+}
+
+// abiEntries flattens the functions, events and errors of an ABI. The
+// fallback and receive functions have no selector and are listed as
+// fallback() and receive().
+func abiEntries(vec abi.ABI) []abiEntry {
+	var entries []abiEntry
+	special := map[string]abi.Method{"fallback": vec.Fallback, "receive": vec.Receive}
+	for name, m := range special {
+		if m.Type == abi.Constructor {
+			// The zero Method, the ABI declares no such function.
+			continue
+		}
+
+		entries = append(entries, abiEntry{
+			kind:       "function",
+			name:       name,
+			sig:        name + "()",
+			mutability: m.StateMutability,
+		})
+	}
+
+	for _, m := range vec.Methods {
+		var outs []string
+		for _, out := range m.Outputs {
+			outs = append(outs, out.Type.String())
+		}
+
+		entries = append(entries, abiEntry{
+			kind:       "function",
+			name:       m.RawName,
+			sig:        m.Sig,
+			id:         fmt.Sprintf("%#x", m.ID),
+			detail:     "(" + strings.Join(outs, ",") + ")",
+			mutability: m.StateMutability,
+		})
+	}
+
+	for _, e := range vec.Events {
+		var indexed []string
+		for _, in := range e.Inputs {
+			indexed = append(indexed, fmt.Sprint(in.Indexed))
+		}
+
+		entries = append(entries, abiEntry{
+			kind:   "event",
+			name:   e.RawName,
+			sig:    e.Sig,
+			id:     e.ID.Hex(),
+			detail: fmt.Sprintf("indexed(%s) anonymous(%v)", strings.Join(indexed, ","), e.Anonymous),
+		})
+	}
+
+	for _, e := range vec.Errors {
+		entries = append(entries, abiEntry{
+			kind: "error",
+			name: e.Name,
+			sig:  e.Sig,
+			id:   fmt.Sprintf("%#x", e.ID[:4]),
+		})
+	}
+
+	return entries
+}
+
+// diffABI compares two ABIs by signature. Removing or altering an entry is
+// breaking, adding one is not. A function that stays read-only or gains the
+// ability to receive value stays compatible.
+func diffABI(old, new abi.ABI) []abiChange {
+	index := func(entries []abiEntry) (map[string]abiEntry, map[string]bool) {
+		sigs, names := make(map[string]abiEntry), make(map[string]bool)
+		for _, e := range entries {
+			sigs[e.kind+" "+e.sig] = e
+			names[e.kind+" "+e.name] = true
+		}
+		return sigs, names
+	}
+
+	oldSigs, oldNames := index(abiEntries(old))
+	newSigs, newNames := index(abiEntries(new))
+
+	var changes []abiChange
+	for key, o := range oldSigs {
+		n, ok := newSigs[key]
+		switch {
+		case !ok && newNames[o.kind+" "+o.name]:
+			changes = append(changes, abiChange{entry: o, verb: "changed", note: "parameters changed", breaking: true})
+		case !ok:
+			changes = append(changes, abiChange{entry: o, verb: "removed", breaking: true})
+		case o.detail != n.detail:
+			changes = append(changes, abiChange{entry: o, verb: "changed", note: o.detail + " -> " + n.detail, breaking: true})
+		case o.mutability != n.mutability:
+			changes = append(changes, abiChange{
+				entry:    o,
+				verb:     "changed",
+				note:     o.mutability + " -> " + n.mutability,
+				breaking: mutabilityBreaks(o.mutability, n.mutability),
+			})
+		}
+	}
+
+	for key, n := range newSigs {
+		if _, ok := oldSigs[key]; ok {
+			continue
+		}
+
+		if oldNames[n.kind+" "+n.name] {
+			changes = append(changes, abiChange{entry: n, verb: "added", note: "replaces or overloads an existing " + n.kind})
+			continue
+		}
+
+		changes = append(changes, abiChange{entry: n, verb: "added"})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i].entry, changes[j].entry
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		if a.sig != b.sig {
+			return a.sig < b.sig
+		}
+		return changes[i].verb < changes[j].verb
+	})
+
+	return changes
+}
+
+// mutabilityBreaks reports whether callers relying on the old state
+// mutability of a function break with the new one.
+func mutabilityBreaks(old, new string) bool {
+	readOnly := func(m string) bool { return m == "view" || m == "pure" }
+	switch {
+	case readOnly(old):
+		return !readOnly(new)
+	case old == "payable":
+		return new != "payable"
+	default:
+		return false
+	}
+}
@@ -0,0 +1,139 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+func TestDiffABI(t *testing.T) {
+	fn := func(sig, mutability string) string {
+		name, in, _ := strings.Cut(strings.TrimSuffix(sig, ")"), "(")
+		var inputs []string
+		for _, kind := range strings.Split(in, ",") {
+			if kind != "" {
+				inputs = append(inputs, fmt.Sprintf(`{"name":"","type":%q}`, kind))
+			}
+		}
+		return fmt.Sprintf(`{"type":"function","name":%q,"stateMutability":%q,"inputs":[%s],"outputs":[]}`, name, mutability, strings.Join(inputs, ","))
+	}
+
+	tests := []struct {
+		name     string
+		old, new []string
+		want     []string
+	}{
+		{
+			name: "unchanged",
+			old:  []string{fn("f(uint256)", "view")},
+			new:  []string{fn("f(uint256)", "view")},
+		},
+		{
+			name: "added",
+			old:  []string{fn("f()", "view")},
+			new:  []string{fn("f()", "view"), fn("g()", "nonpayable")},
+			want: []string{"added function g()"},
+		},
+		{
+			name: "removed",
+			old:  []string{fn("f()", "view"), fn("g()", "nonpayable")},
+			new:  []string{fn("f()", "view")},
+			want: []string{"removed function g() (breaking)"},
+		},
+		{
+			name: "parameters changed",
+			old:  []string{fn("f(uint256)", "nonpayable")},
+			new:  []string{fn("f(address)", "nonpayable")},
+			want: []string{"added function f(address): replaces or overloads an existing function", "changed function f(uint256): parameters changed (breaking)"},
+		},
+		{
+			name: "outputs changed",
+			old:  []string{`{"type":"function","name":"f","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]}`},
+			new:  []string{`{"type":"function","name":"f","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bool"}]}`},
+			want: []string{"changed function f(): (uint256) -> (bool) (breaking)"},
+		},
+		{
+			name: "stays read-only",
+			old:  []string{fn("f()", "view")},
+			new:  []string{fn("f()", "pure")},
+			want: []string{"changed function f(): view -> pure"},
+		},
+		{
+			name: "no longer read-only",
+			old:  []string{fn("f()", "view")},
+			new:  []string{fn("f()", "nonpayable")},
+			want: []string{"changed function f(): view -> nonpayable (breaking)"},
+		},
+		{
+			name: "becomes payable",
+			old:  []string{fn("f()", "nonpayable")},
+			new:  []string{fn("f()", "payable")},
+			want: []string{"changed function f(): nonpayable -> payable"},
+		},
+		{
+			name: "no longer payable",
+			old:  []string{fn("f()", "payable")},
+			new:  []string{fn("f()", "nonpayable")},
+			want: []string{"changed function f(): payable -> nonpayable (breaking)"},
+		},
+		{
+			name: "receive removed",
+			old:  []string{`{"type":"receive","stateMutability":"payable"}`},
+			want: []string{"removed function receive() (breaking)"},
+		},
+		{
+			name: "event indexing changed",
+			old:  []string{`{"type":"event","name":"E","inputs":[{"name":"a","type":"address","indexed":true}]}`},
+			new:  []string{`{"type":"event","name":"E","inputs":[{"name":"a","type":"address","indexed":false}]}`},
+			want: []string{"changed event E(address): indexed(true) anonymous(false) -> indexed(false) anonymous(false) (breaking)"},
+		},
+		{
+			name: "error added",
+			new:  []string{`{"type":"error","name":"Denied","inputs":[]}`},
+			want: []string{"added error Denied()"},
+		},
+	}
+
+	for _, tt := range tests {
+		var vecs [2]abi.ABI
+		for i, entries := range [][]string{tt.old, tt.new} {
+			var err error
+			vecs[i], err = abi.JSON(strings.NewReader("[" + strings.Join(entries, ",") + "]"))
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+		}
+
+		// The changes are compared without the selectors and topics.
+		var got []string
+		for _, c := range diffABI(vecs[0], vecs[1]) {
+			c.entry.id = ""
+			got = append(got, strings.Join(strings.Fields(c.String()), " "))
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: diffABI = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
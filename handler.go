@@ -0,0 +1,86 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// writeHTTP generates http.go in the bindings package exposing every
+// function of the bindings through an http.Handler. The inputs of the
+// functions must pass checkArgs.
+func writeHTTP(out string, data TemplateData, hdr fileHeader) error {
+	fnMap := map[string]any{
+		"httpParse": func(in Argument, i int) string {
+			src := fmt.Sprintf("args.Get(%q)", argName(in, i))
+			return parseArg(in, i, src, "httpError(w, err, http.StatusBadRequest)\n\t\t\treturn", "httpError(w, err, http.StatusInternalServerError)\n\t\t\treturn")
+		},
+		"httpCall": func(fn Function) string {
+			return httpCall(fn, identFunc(data.Unexported)(fn.Name), data.Any)
+		},
+		"ident": identFunc(data.Unexported),
+	}
+
+	templ := template.Must(template.New("").Funcs(fnMap).Parse(TemplHTTP))
+
+	var b bytes.Buffer
+	err := templ.Execute(&b, HTTPData{
+		Package: data.Package,
+		Any:     data.Any,
+		Funcs:   data.Funcs,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeGo(filepath.Join(out, "http.go"), b.Bytes(), hdr)
+}
+
+func httpCall(fn Function, name, any string) string {
+	var args, rets, vals []string
+	for i := range fn.Inputs {
+		args = append(args, fmt.Sprintf("arg%d", i))
+	}
+
+	call := fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+	fail := "\n\t\t\thttpError(w, err, http.StatusInternalServerError)\n\t\t\treturn\n\t\t}"
+
+	var convert string
+	for i, out := range fn.Outputs {
+		rets = append(rets, fmt.Sprintf("r%d", i))
+		// Types bound with --type-map are replied as the *big.Int they
+		// convert to.
+		if out.Map != nil {
+			convert += fmt.Sprintf("\n\t\tb%d, err := toBig%s(r%d)\n\t\tif err != nil {%s", i, out.Map.Shim, i, fail)
+			vals = append(vals, fmt.Sprintf("b%d", i))
+			continue
+		}
+		vals = append(vals, fmt.Sprintf("r%d", i))
+	}
+
+	if len(rets) == 0 {
+		return fmt.Sprintf("if err := %s; err != nil {%s\n\t\thttpReply(w, %q, []%s{})", call, fail, fn.Method, any)
+	}
+
+	return fmt.Sprintf("%s, err := %s\n\t\tif err != nil {%s%s\n\t\thttpReply(w, %q, []%s{%s})", strings.Join(rets, ", "), call, fail, convert, fn.Method, any, strings.Join(vals, ", "))
+}
@@ -0,0 +1,122 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// fileHeader holds the options applied to the top of every generated Go
+// file.
+type fileHeader struct {
+	// Generated replaces the "Code generated" line of the templates, if set.
+	Generated string
+	// License is the comment block placed above the generated code, such as
+	// a copyright notice or an SPDX identifier.
+	License string
+	// BuildTags are build constraints that must all hold, such as
+	// "integration" or "!wasm".
+	BuildTags []string
+}
+
+// licenseComment turns the text of a header file, and an optional SPDX
+// license identifier, into a comment block. Lines that are not already
+// comments are commented out.
+func licenseComment(text, spdx string) (string, error) {
+	var lines []string
+	if text = strings.TrimRight(text, "\r\n"); text != "" {
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimRight(line, "\r")
+			switch {
+			case strings.HasPrefix(line, "//"):
+			case strings.TrimSpace(line) == "":
+				line = "//"
+			default:
+				line = "// " + line
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	if spdx != "" {
+		if !regexp.MustCompile(`^[A-Za-z0-9.+:() -]+$`).MatchString(spdx) {
+			return "", fmt.Errorf("spdx: invalid license expression %q", spdx)
+		}
+		lines = append(lines, "// SPDX-License-Identifier: "+spdx)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// provenance returns the "Code generated" line recording the evmbind version
+// and the hashes of the ABI and bytecode files the code was generated from.
+// The bytecode is omitted when nil.
+func provenance(abi, bin []byte) string {
+	if bin == nil {
+		return fmt.Sprintf("// Code generated by evmbind %s; abi=sha256:%x; DO NOT EDIT.", readBuildInfo(), sha256.Sum256(abi))
+	}
+
+	return fmt.Sprintf("// Code generated by evmbind %s; abi=sha256:%x; bin=sha256:%x; DO NOT EDIT.", readBuildInfo(), sha256.Sum256(abi), sha256.Sum256(bin))
+}
+
+// parseBuildTags parses a comma separated list of build tags, each
+// optionally negated with a leading "!".
+func parseBuildTags(s string) ([]string, error) {
+	re := regexp.MustCompile(`^!?[A-Za-z0-9_.]+$`)
+
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		tag = strings.TrimSpace(tag)
+		if !re.MatchString(tag) {
+			return nil, fmt.Errorf("build-tags: invalid build tag %q", tag)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// writeGo writes the generated Go source src to path, placing the license
+// above its leading "Code generated" line and the build constraints below it.
+func writeGo(path string, src []byte, hdr fileHeader) error {
+	generated, rest, _ := bytes.Cut(src, []byte("\n"))
+
+	var b bytes.Buffer
+	if hdr.License != "" {
+		b.WriteString(hdr.License)
+		b.WriteString("\n\n")
+	}
+	if hdr.Generated != "" {
+		generated = []byte(hdr.Generated)
+	}
+	b.Write(generated)
+	b.WriteString("\n")
+	if len(hdr.BuildTags) > 0 {
+		fmt.Fprintf(&b, "//go:build %s\n", strings.Join(hdr.BuildTags, " && "))
+		fmt.Fprintf(&b, "// +build %s\n\n", strings.Join(hdr.BuildTags, ","))
+	}
+	b.Write(rest)
+
+	return ioutil.WriteFile(path, b.Bytes(), 0644)
+}
@@ -20,57 +20,352 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"go/token"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
+	"unicode"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/compiler"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/params"
 
 	"github.com/urfave/cli/v2"
 )
 
 func main() {
 	app := &cli.App{
-		Name:   "evmbind",
-		Usage:  "generate Go bindings for EVM contracts",
-		Action: binder,
+		Name:    "evmbind",
+		Usage:   "generate Go bindings for EVM contracts",
+		Action:  binder,
+		Version: readBuildInfo().String(),
+		Commands: []*cli.Command{
+			{
+				Name:   "version",
+				Usage:  "print the version, commit and go-ethereum version of evmbind",
+				Action: versioner,
+			},
+			{
+				Name:      "diff",
+				Usage:     "report changes between two ABIs, failing on breaking ones",
+				ArgsUsage: "<old.abi> <new.abi>",
+				Action:    differ,
+			},
+			{
+				Name:   "upgrade-check",
+				Usage:  "check that a new storage layout can safely replace an old one",
+				Action: upgradeCheck,
+				Flags: []cli.Flag{
+					&cli.PathFlag{
+						Name:     "old-layout",
+						Usage:    "path to the storage layout of the deployed implementation",
+						Required: true,
+					},
+					&cli.PathFlag{
+						Name:     "new-layout",
+						Usage:    "path to the storage layout of the new implementation",
+						Required: true,
+					},
+				},
+			},
+		},
 		Flags: []cli.Flag{
 			&cli.PathFlag{
-				Name:     "abi",
-				Usage:    "path to the ABI JSON file to bind against",
-				Required: true,
+				Name:  "abi",
+				Usage: "path to the ABI JSON file to bind against (required)",
+			},
+			&cli.PathFlag{
+				Name:  "bin",
+				Usage: "path to the bytecode binary to bind against (required)",
+			},
+			&cli.PathFlag{
+				Name:  "bin-runtime",
+				Usage: "path to the deployed bytecode to bind against, instead of --bin with --cr",
+			},
+			&cli.PathFlag{
+				Name:  "sol",
+				Usage: "path to a Solidity source file to compile with solc and bind against, instead of --abi and --bin",
+			},
+			&cli.StringFlag{
+				Name:  "solc",
+				Usage: "solc executable compiling --sol, checked against the pragma solidity of the source",
+				Value: "solc",
+			},
+			&cli.StringSliceFlag{
+				Name:  "remap",
+				Usage: "import remappings passed to solc, such as @openzeppelin=node_modules/@openzeppelin",
+			},
+			&cli.PathFlag{
+				Name:  "base-path",
+				Usage: "directory solc resolves imports against, also used to read the sources of --standard-json-input",
+			},
+			&cli.StringSliceFlag{
+				Name:  "include-path",
+				Usage: "further directories holding imported libraries, such as node_modules; needs --base-path",
+			},
+			&cli.PathFlag{
+				Name:  "standard-json",
+				Usage: "path to a solc --standard-json output to bind against, instead of --abi and --bin",
+			},
+			&cli.PathFlag{
+				Name:  "combined-json",
+				Usage: "path to a solc --combined-json output to bind against, instead of --abi and --bin",
+			},
+			&cli.PathFlag{
+				Name:  "standard-json-input",
+				Usage: "write the solc --standard-json input reproducing the compilation of the contract to the given path, for verification services",
+			},
+			&cli.StringFlag{
+				Name:  "pkg",
+				Usage: "name of the package to generate the bindings into (required)",
 			},
 			&cli.PathFlag{
-				Name:     "bin",
-				Usage:    "path to the bytecode binary to bind against",
-				Required: true,
+				Name:  "out",
+				Usage: "path to the output dir (required)",
 			},
 			&cli.StringFlag{
-				Name:     "pkg",
-				Usage:    "name of the package to generate the bindings into",
-				Required: true,
+				Name:  "contract",
+				Usage: "name of the contract, used to find it in deployment records; with compiler output, comma separated names, path:Name or glob patterns selecting the contracts to bind, each in its own package under --out when several",
 			},
 			&cli.PathFlag{
-				Name:     "out",
-				Usage:    "path to the output dir",
-				Required: true,
+				Name:  "deployments",
+				Usage: "path to a JSON manifest of chain ID to contract name to address, or a hardhat-deploy export",
+			},
+			&cli.StringSliceFlag{
+				Name:  "broadcast",
+				Usage: "path to a forge script broadcast log (run-latest.json), may be repeated",
 			},
 			&cli.BoolFlag{
 				Name:  "cr",
 				Usage: "remove creation code from the binary",
 			},
+			&cli.BoolFlag{
+				Name:  "strict-size",
+				Usage: "fail instead of warning when the code exceeds the EIP-170 or EIP-3860 size limits",
+			},
+			&cli.StringFlag{
+				Name:  "only",
+				Usage: "regular expression selecting the methods to bind, such as 'transfer|balanceOf'",
+			},
+			&cli.StringFlag{
+				Name:  "exclude",
+				Usage: "regular expression selecting methods to leave out, such as '^_'",
+			},
+			&cli.StringSliceFlag{
+				Name:  "alias",
+				Usage: "rename the Go identifier of a method or event, as name=Alias, may be repeated",
+			},
+			&cli.StringFlag{
+				Name:  "go-version",
+				Usage: "oldest Go release the generated code must build with",
+				Value: "1.18",
+			},
+			&cli.BoolFlag{
+				Name:  "unexported",
+				Usage: "generate unexported function, type and variable names, except ABI and Bin, for wrapping in a hand-written API",
+			},
+			&cli.StringSliceFlag{
+				Name:  "type-map",
+				Usage: "bind an ABI type, or a method.param, to a Go type such as uint256=github.com/shopspring/decimal.Decimal, may be repeated",
+			},
+			&cli.StringSliceFlag{
+				Name:  "time-fields",
+				Usage: "names of integer parameters to bind as time.Time",
+			},
+			&cli.PathFlag{
+				Name:  "userdoc",
+				Usage: "path to a solc userdoc, or an artifact holding one, whose notices document the generated code",
+			},
+			&cli.PathFlag{
+				Name:  "devdoc",
+				Usage: "path to a solc devdoc, or an artifact holding one, whose details, parameters and return values document the generated code",
+			},
+			&cli.PathFlag{
+				Name:  "storage-layout",
+				Usage: "path to a solc storage layout to generate storage slot accessors from",
+			},
+			&cli.PathFlag{
+				Name:  "srcmap",
+				Usage: "path to the solc srcmap-runtime of the code to generate a ResolvePC lookup and LCOV coverage from",
+			},
+			&cli.StringSliceFlag{
+				Name:  "sources",
+				Usage: "source files in the order of the compiler source list, resolving --srcmap offsets to lines",
+			},
+			&cli.StringFlag{
+				Name:  "packed",
+				Usage: "semicolon separated signatures to generate abi.encodePacked helpers for, e.g. \"Order(address maker,uint256 amount)\"",
+			},
+			&cli.PathFlag{
+				Name:  "header-file",
+				Usage: "file whose text is placed as a comment at the top of the generated files",
+			},
+			&cli.StringFlag{
+				Name:  "spdx",
+				Usage: "SPDX license identifier, such as MIT, stamped on the generated files",
+			},
+			&cli.StringFlag{
+				Name:  "build-tags",
+				Usage: "comma separated build tags, such as \"integration,!wasm\", constraining the generated files",
+			},
+			&cli.BoolFlag{
+				Name:  "codec-only",
+				Usage: "only generate functions packing and unpacking calldata, results and logs, without executing code; --bin is not needed",
+			},
+			&cli.BoolFlag{
+				Name:  "embed-files",
+				Usage: "write the ABI and bytecode next to the bindings as <contract>.abi and <contract>.bin and load them with go:embed",
+			},
+			&cli.BoolFlag{
+				Name:  "compress-bin",
+				Usage: "embed the code gzip compressed, decompressing it on first use; Bin becomes a function",
+			},
+			&cli.BoolFlag{
+				Name:  "split",
+				Usage: "write the bindings as <contract>_types.go, <contract>_events.go and <contract>_caller.go instead of evm.go",
+			},
+			&cli.StringFlag{
+				Name:  "init-module",
+				Usage: "write a go.mod for the given module path to the output directory, unless one exists",
+			},
+			&cli.BoolFlag{
+				Name:  "with-calls",
+				Usage: "also generate a <Method>Call type per method to build, pack and execute calls separately",
+			},
+			&cli.BoolFlag{
+				Name:  "with-cli",
+				Usage: "also generate a command line tool under cmd/<pkg>ctl",
+			},
+			&cli.BoolFlag{
+				Name:  "with-http",
+				Usage: "also generate an http.Handler serving the contract methods",
+			},
+			&cli.BoolFlag{
+				Name:  "with-schema",
+				Usage: "also write JSON Schema documents for method and event arguments under schema/",
+			},
 		},
 	}
 
-	app.Run(os.Args)
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// maxInitCodeSize is the EIP-3860 limit on init code, twice the EIP-170
+// runtime code limit.
+const maxInitCodeSize = 2 * params.MaxCodeSize
+
+// checkSize warns when size exceeds limit, or fails with --strict-size.
+func checkSize(ctx *cli.Context, what string, size, limit int, eip string) error {
+	if size <= limit {
+		return nil
+	}
+
+	err := fmt.Errorf("%s is %d bytes, over the %s limit of %d bytes", what, size, eip, limit)
+	if ctx.Bool("strict-size") {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "warning:", err)
+	return nil
+}
+
+// filterMethods removes the methods whose names do not match only, if set,
+// or match exclude, if set.
+func filterMethods(methods map[string]abi.Method, only, exclude string) error {
+	var keep, drop *regexp.Regexp
+	var err error
+	if only != "" {
+		if keep, err = regexp.Compile(only); err != nil {
+			return fmt.Errorf("only: %v", err)
+		}
+	}
+	if exclude != "" {
+		if drop, err = regexp.Compile(exclude); err != nil {
+			return fmt.Errorf("exclude: %v", err)
+		}
+	}
+
+	for name := range methods {
+		if (keep != nil && !keep.MatchString(name)) || (drop != nil && drop.MatchString(name)) {
+			delete(methods, name)
+		}
+	}
+
+	return nil
+}
+
+// goString returns s as a Go string literal, a raw string unless s holds a
+// backquote or a carriage return, which raw strings cannot keep.
+func goString(s string) string {
+	if strings.ContainsAny(s, "`\r") {
+		return strconv.Quote(s)
+	}
+
+	return "`" + s + "`"
+}
+
+// normalizeBin returns the hex encoded code of a bytecode file without the
+// 0x prefix and the whitespace around and within it, reporting the offset
+// of the first character that is not hex.
+func normalizeBin(src []byte) (string, error) {
+	start := len(src) - len(bytes.TrimLeftFunc(src, unicode.IsSpace))
+	if bytes.HasPrefix(src[start:], []byte("0x")) || bytes.HasPrefix(src[start:], []byte("0X")) {
+		start += 2
+	}
+
+	var b strings.Builder
+	for i := start; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+			b.WriteByte(c)
+		case unicode.IsSpace(rune(c)):
+		case c == '_' && bytes.HasPrefix(src[i:], []byte("__$")):
+			return "", fmt.Errorf("bin: unlinked library placeholder at offset %d", i)
+		default:
+			return "", fmt.Errorf("bin: invalid hex character %q at offset %d", c, i)
+		}
+	}
+
+	if b.Len()%2 != 0 {
+		return "", fmt.Errorf("bin: odd number of hex digits")
+	}
+
+	return strings.ToLower(b.String()), nil
+}
+
+// gzipBin returns the gzip compressed code of the hex encoded bin.
+func gzipBin(bin string) ([]byte, error) {
+	var b bytes.Buffer
+	w, err := gzip.NewWriterLevel(&b, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(common.Hex2Bytes(bin)); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
 }
 
 func removeCreationCode(bin string) string {
@@ -84,21 +379,228 @@ func removeCreationCode(bin string) string {
 }
 
 func binder(ctx *cli.Context) error {
-	abiPath := ctx.Path("abi")
-	src0, err := ioutil.ReadFile(abiPath)
+	// The code taken from compiler output is the runtime code, so there is
+	// no creation code to remove.
+	compiledFrom := ""
+	for _, name := range []string{"sol", "standard-json", "combined-json"} {
+		if !ctx.IsSet(name) {
+			continue
+		}
+
+		for _, other := range []string{"abi", "bin", "bin-runtime", "cr", "sol", "standard-json"} {
+			if other != name && ctx.IsSet(other) {
+				return fmt.Errorf("%s: --%s cannot be used with --%s", name, other, name)
+			}
+		}
+		compiledFrom = name
+	}
+
+	if ctx.IsSet("bin-runtime") {
+		for _, other := range []string{"bin", "cr"} {
+			if ctx.IsSet(other) {
+				return fmt.Errorf("bin-runtime: --%s cannot be used with --bin-runtime", other)
+			}
+		}
+	}
+
+	if ctx.IsSet("standard-json-input") && compiledFrom == "" {
+		return fmt.Errorf("standard-json-input: needs --sol, --standard-json or --combined-json")
+	}
+
+	// The flags are checked here rather than marked required so that
+	// subcommands can run without them.
+	for _, name := range []string{"abi", "bin", "pkg", "out"} {
+		// The package is checked once the contracts are selected, as
+		// several get packages of their own.
+		if compiledFrom != "" && name != "out" {
+			continue
+		}
+
+		if !ctx.IsSet(name) && (name != "bin" || !ctx.Bool("codec-only") && !ctx.IsSet("bin-runtime")) {
+			return fmt.Errorf("required flag %q not set", name)
+		}
+	}
+
+	if ctx.Bool("codec-only") {
+		for _, name := range []string{"split", "compress-bin", "with-cli", "with-http"} {
+			if ctx.Bool(name) {
+				return fmt.Errorf("codec-only: --%s needs the executing bindings", name)
+			}
+		}
+
+		if ctx.IsSet("srcmap") {
+			return fmt.Errorf("codec-only: --srcmap needs the runtime code")
+		}
+	}
+
+	if compiledFrom == "" {
+		src0, err := ioutil.ReadFile(ctx.Path("abi"))
+		if err != nil {
+			return err
+		}
+
+		// The deployed bytecode has no creation code to remove and is
+		// embedded as it is.
+		binPath := ctx.Path("bin")
+		if ctx.IsSet("bin-runtime") {
+			binPath = ctx.Path("bin-runtime")
+		}
+
+		var src1 []byte
+		if binPath != "" {
+			src1, err = ioutil.ReadFile(binPath)
+			if err != nil {
+				return err
+			}
+		}
+
+		// The contract may be given as path:Name, while the files and
+		// deployment records only use the name.
+		contract := ctx.String("contract")
+		if i := strings.LastIndex(contract, ":"); i >= 0 {
+			contract = contract[i+1:]
+		}
+
+		return bind(ctx, bindTarget{
+			ABI:      src0,
+			Bin:      src1,
+			Contract: contract,
+			Package:  ctx.String("pkg"),
+			Out:      ctx.Path("out"),
+		})
+	}
+
+	opts := solcOptions{
+		Solc:         ctx.String("solc"),
+		Remappings:   ctx.StringSlice("remap"),
+		BasePath:     ctx.Path("base-path"),
+		IncludePaths: ctx.StringSlice("include-path"),
+	}
+
+	var (
+		contracts map[string]*compiler.Contract
+		err       error
+	)
+	switch compiledFrom {
+	case "sol":
+		contracts, err = compileSolidity(opts, ctx.Path("sol"))
+	case "standard-json":
+		contracts, err = readStandardJSON(ctx.Path("standard-json"))
+	case "combined-json":
+		contracts, err = readCombinedJSON(ctx.Path("combined-json"))
+	}
 	if err != nil {
 		return err
 	}
 
-	binPath := ctx.Path("bin")
-	src1, err := ioutil.ReadFile(binPath)
+	keys, err := selectContracts(contracts, ctx.Path("sol"), ctx.String("contract"))
 	if err != nil {
 		return err
 	}
 
+	// Several contracts are each bound in a package named after them, in
+	// a directory of the same name under out.
+	if len(keys) > 1 {
+		for _, name := range []string{"pkg", "init-module", "standard-json-input", "storage-layout", "srcmap", "userdoc", "devdoc"} {
+			if ctx.IsSet(name) {
+				return fmt.Errorf("contract: --%s applies to a single contract, but %d are selected", name, len(keys))
+			}
+		}
+	} else if !ctx.IsSet("pkg") {
+		return fmt.Errorf("required flag %q not set", "pkg")
+	} else if path := ctx.Path("standard-json-input"); path != "" {
+		if err := writeStandardInput(path, contracts[keys[0]], opts.roots()); err != nil {
+			return err
+		}
+	}
+
+	seen := make(map[string]string)
+	for _, key := range keys {
+		compiled := contracts[key]
+		src0, err := json.Marshal(compiled.Info.AbiDefinition)
+		if err != nil {
+			return err
+		}
+
+		t := bindTarget{
+			ABI:      src0,
+			Bin:      []byte(strings.TrimPrefix(compiled.RuntimeCode, "0x")),
+			Compiled: compiled,
+			Contract: key[strings.LastIndex(key, ":")+1:],
+			Package:  ctx.String("pkg"),
+			Out:      ctx.Path("out"),
+		}
+
+		if len(keys) > 1 {
+			t.Package = strings.ToLower(t.Contract)
+			if token.IsKeyword(t.Package) {
+				return fmt.Errorf("contract: the package name %s of %s is a Go keyword", t.Package, key)
+			}
+
+			if other, ok := seen[t.Package]; ok {
+				return fmt.Errorf("contract: %s and %s would share the package %s", other, key, t.Package)
+			}
+			seen[t.Package] = key
+
+			t.Out = filepath.Join(t.Out, t.Package)
+			if err := os.MkdirAll(t.Out, 0755); err != nil {
+				return err
+			}
+		}
+
+		if err := bind(ctx, t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bindTarget is a contract to generate bindings for.
+type bindTarget struct {
+	// ABI is the ABI JSON of the contract.
+	ABI []byte
+	// Bin is the hex encoded code of the contract, nil with --codec-only.
+	Bin []byte
+	// Compiled is the compiler output holding the contract, if any.
+	Compiled *compiler.Contract
+	// Contract is the name of the contract, if known.
+	Contract string
+	// Package is the name of the bindings package.
+	Package string
+	// Out is the directory the bindings are written to.
+	Out string
+}
+
+// bind generates the bindings of t as configured by the flags of ctx.
+func bind(ctx *cli.Context, t bindTarget) error {
+	var err error
+	hdr := fileHeader{Generated: provenance(t.ABI, t.Bin)}
+	if ctx.IsSet("header-file") || ctx.IsSet("spdx") {
+		var text []byte
+		if path := ctx.Path("header-file"); path != "" {
+			text, err = ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+		}
+
+		hdr.License, err = licenseComment(string(text), ctx.String("spdx"))
+		if err != nil {
+			return err
+		}
+	}
+
+	if tags := ctx.String("build-tags"); tags != "" {
+		hdr.BuildTags, err = parseBuildTags(tags)
+		if err != nil {
+			return err
+		}
+	}
+
 	// stringify abi
 	var abiRaw json.RawMessage
-	err = json.Unmarshal(src0, &abiRaw)
+	err = json.Unmarshal(t.ABI, &abiRaw)
 	if err != nil {
 		return err
 	}
@@ -108,68 +610,477 @@ func binder(ctx *cli.Context) error {
 		return err
 	}
 
-	abivet := strings.ReplaceAll(string(abiStr), "\"", "\\\"")
-	binvet := string(src1)
+	abivet := goString(string(abiStr))
+	binvet, err := normalizeBin(t.Bin)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool("cr") && !ctx.Bool("codec-only") {
+		// evmbind passes no constructor arguments, so the init code is the
+		// creation code alone.
+		err = checkSize(ctx, "init code", len(common.FromHex(binvet)), maxInitCodeSize, "EIP-3860")
+		if err != nil {
+			return err
+		}
 
-	if ctx.Bool("cr") {
 		binvet = removeCreationCode(binvet)
+	} else if t.Compiled != nil && !ctx.Bool("codec-only") {
+		// The creation code of compiled contracts is measured from its hex,
+		// which may hold unlinked library placeholders.
+		size := len(strings.TrimPrefix(t.Compiled.Code, "0x")) / 2
+		err = checkSize(ctx, "init code", size, maxInitCodeSize, "EIP-3860")
+		if err != nil {
+			return err
+		}
+	}
+
+	if !ctx.Bool("codec-only") {
+		err = checkSize(ctx, "runtime code", len(common.FromHex(binvet)), params.MaxCodeSize, "EIP-170")
+		if err != nil {
+			return err
+		}
 	}
 
 	var templateData TemplateData
-	templateData.Package = ctx.String("pkg")
+	templateData.Package = t.Package
+	templateData.Contract = t.Contract
+	if templateData.Contract == "" {
+		templateData.Contract = t.Package
+	}
 	templateData.ABI = abivet
 	templateData.Bin = binvet
+	templateData.Unexported = ctx.Bool("unexported")
+	templateData.CodecOnly = ctx.Bool("codec-only")
+	templateData.Calls = ctx.Bool("with-calls")
+	templateData.Coverage = ctx.IsSet("srcmap")
+
+	templateData.GoVersion, err = parseGoVersion(ctx.String("go-version"))
+	if err != nil {
+		return err
+	}
+
+	if templateData.CodecOnly && templateData.GoVersion < 18 {
+		return fmt.Errorf("codec-only: the generated code needs Go 1.18 or later")
+	}
+
+	if templateData.Calls && templateData.GoVersion < 18 {
+		return fmt.Errorf("with-calls: the generated code needs Go 1.18 or later")
+	}
+
+	templateData.Any = "any"
+	if templateData.GoVersion < 18 {
+		templateData.Any = "interface{}"
+	}
+
+	vec, err := abi.JSON(strings.NewReader(string(t.ABI)))
+	if err != nil {
+		return err
+	}
+
+	aliases, err := parseAliases(ctx.StringSlice("alias"))
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool)
+	for name := range vec.Methods {
+		known[name] = true
+	}
+	for name := range vec.Events {
+		known[name] = true
+	}
+
+	if err := checkAliases(aliases, known); err != nil {
+		return err
+	}
+
+	err = filterMethods(vec.Methods, ctx.String("only"), ctx.String("exclude"))
+	if err != nil {
+		return err
+	}
 
-	vec, err := abi.JSON(strings.NewReader(string(src0)))
+	mapper, err := parseTypeMaps(ctx.StringSlice("type-map"))
 	if err != nil {
 		return err
 	}
 
+	// timeFields maps the --time-fields names to whether a parameter has
+	// the name.
+	timeFields := make(map[string]bool)
+	for _, name := range ctx.StringSlice("time-fields") {
+		timeFields[name] = false
+	}
+
+	var userdoc, devdoc natspecDoc
+	if path := ctx.Path("userdoc"); path != "" {
+		doc, err := readNatspec(path, "userdoc")
+		if err != nil {
+			return err
+		}
+		userdoc = *doc
+	} else if t.Compiled != nil {
+		if userdoc, err = natspecOf(t.Compiled.Info.UserDoc); err != nil {
+			return err
+		}
+	}
+
+	if path := ctx.Path("devdoc"); path != "" {
+		doc, err := readNatspec(path, "devdoc")
+		if err != nil {
+			return err
+		}
+		devdoc = *doc
+	} else if t.Compiled != nil {
+		if devdoc, err = natspecOf(t.Compiled.Info.DeveloperDoc); err != nil {
+			return err
+		}
+	}
+
 	for _, method := range vec.Methods {
 		var fn Function
 		// fn.Name first letter is upper case
-		fn.Name = strings.ToUpper(string(method.Name[0])) + string(method.Name[1:])
+		fn.Name = goName(method.Name, aliases)
 		fn.Method = method.Name
 		fn.Id = hexutil.Encode(method.ID)
 		fn.Raw = method.String()
+		fn.Sig = method.Sig
+		fn.Constant = method.IsConstant()
 
 		for _, input := range method.Inputs {
+			if err := checkType(input.Type, input.Name, method.Name); err != nil {
+				return err
+			}
+
 			args := Argument{
 				Name: input.Name,
 				Type: input.Type,
 			}
 
+			if _, ok := timeFields[input.Name]; ok {
+				if input.Type.T != abi.IntTy && input.Type.T != abi.UintTy {
+					return fmt.Errorf("time-fields: %s of %s is %s, not an integer", input.Name, method.Name, input.Type)
+				}
+				args.Time = true
+				timeFields[input.Name] = true
+			}
+
+			if args.Map, err = mapper.lookup(method.Name, input.Name, input.Type); err != nil {
+				return err
+			}
+
+			if args.Time && args.Map != nil {
+				return fmt.Errorf("type-map: %s of %s is also a time field", input.Name, method.Name)
+			}
+
 			fn.Inputs = append(fn.Inputs, args)
 		}
 
 		for _, output := range method.Outputs {
-			fn.Outputs = append(fn.Outputs, output.Type)
+			if err := checkType(output.Type, output.Name, method.Name); err != nil {
+				return err
+			}
+
+			ret := Argument{
+				Name: output.Name,
+				Type: output.Type,
+			}
+
+			if ret.Map, err = mapper.lookup(method.Name, output.Name, output.Type); err != nil {
+				return err
+			}
+
+			fn.Outputs = append(fn.Outputs, ret)
 		}
+		fn.Doc = natspecText(userdoc.Methods[method.Sig], devdoc.Methods[method.Sig], fn.Inputs, fn.Outputs)
 
 		templateData.Funcs = append(templateData.Funcs, fn)
 	}
+	// The methods are sorted so that the same inputs always generate the
+	// same code.
+	sort.Slice(templateData.Funcs, func(i, j int) bool {
+		return templateData.Funcs[i].Name < templateData.Funcs[j].Name
+	})
+
+	if err := mapper.check(); err != nil {
+		return err
+	}
+
+	var unused []string
+	for name, used := range timeFields {
+		if !used {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return fmt.Errorf("time-fields: no parameter named %s", strings.Join(unused, ", "))
+	}
+	templateData.TypeMaps = typeMaps(templateData.Funcs)
+
+	for _, event := range vec.Events {
+		ev := Event{
+			Name:    goName(event.Name, aliases),
+			Sig:     event.Sig,
+			Topic:     event.ID.Hex(),
+			ABIName:   event.Name,
+			Anonymous: event.Anonymous,
+		}
+
+		for _, input := range event.Inputs {
+			// Only the codec unpacks events into Go values.
+			if templateData.CodecOnly {
+				if err := checkType(input.Type, input.Name, event.Name); err != nil {
+					return err
+				}
+			}
+
+			ev.Inputs = append(ev.Inputs, Argument{
+				Name:    input.Name,
+				Type:    input.Type,
+				Indexed: input.Indexed,
+			})
+		}
+
+		ev.Doc = natspecText(userdoc.Events[event.Sig], devdoc.Events[event.Sig], ev.Inputs, nil)
+		templateData.Events = append(templateData.Events, ev)
+
+		if templateData.CodecOnly && !ev.Anonymous {
+			templateData.EventHandler = strings.ToUpper(templateData.Contract[:1]) + templateData.Contract[1:] + "EventHandler"
+		}
+	}
+	sort.Slice(templateData.Events, func(i, j int) bool {
+		return templateData.Events[i].Name < templateData.Events[j].Name
+	})
+
+	// The amount helpers cannot convert decimals bound with --type-map.
+	var decimalsMapped bool
+	for _, fn := range templateData.Funcs {
+		if fn.Method == "decimals" && len(fn.Outputs) == 1 && fn.Outputs[0].Map != nil {
+			decimalsMapped = true
+		}
+	}
+
+	if method, ok := vec.Methods["decimals"]; ok && len(method.Inputs) == 0 && len(method.Outputs) == 1 && !decimalsMapped && !templateData.CodecOnly {
+		if out := method.Outputs[0].Type; out.T == abi.IntTy || out.T == abi.UintTy {
+			templateData.Decimals = identFunc(templateData.Unexported)(goName("decimals", aliases))
+			templateData.DecimalsBig = bindType(out) == "*big.Int"
+		}
+	}
+
+	// The optional outputs declare identifiers of their own next to the
+	// bindings.
+	reserved := make(map[string]string)
+	reserve := func(flag string, names ...string) {
+		for _, name := range names {
+			name = identFunc(templateData.Unexported)(name)
+			reserved[name] = fmt.Sprintf("the %s declaration of --%s", name, flag)
+		}
+	}
+
+	if ctx.Bool("with-http") {
+		reserve("with-http", "Handler")
+	}
+
+	if ctx.IsSet("deployments") || ctx.IsSet("broadcast") {
+		reserve("deployments", addressesIdents...)
+	}
+
+	var storage StorageData
+	if layout := ctx.Path("storage-layout"); layout != "" {
+		storage, err = storageData(templateData.Package, layout, templateData.Unexported)
+		if err != nil {
+			return err
+		}
+		reserve("storage-layout", storage.idents()...)
+	}
+
+	if ctx.IsSet("srcmap") {
+		reserve("srcmap", srcMapIdents...)
+	}
+
+	var packed PackedData
+	if decl := ctx.String("packed"); decl != "" {
+		packed, err = packedData(templateData.Package, decl, templateData.Unexported)
+		if err != nil {
+			return err
+		}
+		reserve("packed", packed.idents()...)
+	}
+
+	if err := checkIdentifiers(templateData, reserved); err != nil {
+		return err
+	}
+
+	// The command line tool and the HTTP handler parse every argument from
+	// a string, so unsupported inputs are reported before anything is
+	// written.
+	if ctx.Bool("with-cli") {
+		if err := checkCLI(templateData); err != nil {
+			return err
+		}
+	}
+
+	if ctx.Bool("with-http") {
+		if err := checkArgs("http", templateData.Funcs); err != nil {
+			return err
+		}
+	}
 
 	fnMap := map[string]any{
+		"ident":     identFunc(templateData.Unexported),
+		"doc":       docLines,
 		"parseIn":   parseIn,
 		"parseOut":  parseOut,
 		"parseBody": parseBody,
+		"callType": func(fn Function) string {
+			return callType(fn, identFunc(templateData.Unexported), templateData.CodecOnly)
+		},
+		"codecFunc": func(fn Function) string {
+			return codecFunc(fn, identFunc(templateData.Unexported))
+		},
+		"codecEvent": func(event Event) string {
+			return codecEvent(event, identFunc(templateData.Unexported))
+		},
+		"codecDispatch": func(handler string) string {
+			return codecDispatch(handler, templateData.Events, identFunc(templateData.Unexported))
+		},
+		"selectorBytes": func(id string) string {
+			var parts []string
+			for _, b := range common.FromHex(id) {
+				parts = append(parts, fmt.Sprintf("%#02x", b))
+			}
+			return strings.Join(parts, ", ")
+		},
 	}
 
-	templ := template.Must(template.New("").Funcs(fnMap).Parse(Templ))
+	base := strings.ToLower(templateData.Package)
+	if t.Contract != "" {
+		base = strings.ToLower(t.Contract)
+	}
 
-	var b bytes.Buffer
-	err = templ.Execute(&b, templateData)
+	var binGzip []byte
+	if ctx.Bool("compress-bin") {
+		binGzip, err = gzipBin(binvet)
+		if err != nil {
+			return err
+		}
+		templateData.Compress = true
+	}
+
+	if ctx.Bool("embed-files") {
+		templateData.Embed = base
+
+		err = ioutil.WriteFile(filepath.Join(t.Out, base+".abi"), append(abiStr, '\n'), 0644)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case templateData.CodecOnly:
+		case templateData.Compress:
+			err = ioutil.WriteFile(filepath.Join(t.Out, base+".bin.gz"), binGzip, 0644)
+		default:
+			err = ioutil.WriteFile(filepath.Join(t.Out, base+".bin"), []byte(binvet), 0644)
+		}
+		if err != nil {
+			return err
+		}
+	} else if templateData.Compress {
+		templateData.BinGzip = base64.StdEncoding.EncodeToString(binGzip)
+	}
+
+	err = writeBindings(t.Out, base, ctx.Bool("split"), fnMap, templateData, hdr)
 	if err != nil {
 		return err
 	}
 
-	return ioutil.WriteFile(filepath.Join(ctx.Path("out"), "evm.go"), b.Bytes(), 0644)
+	if ctx.IsSet("init-module") {
+		err = writeModule(t.Out, ctx.String("init-module"), templateData.GoVersion)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ctx.IsSet("deployments") || ctx.IsSet("broadcast") {
+		if t.Contract == "" {
+			return fmt.Errorf("deployments: --contract is required to select the contract")
+		}
+
+		var deployments []Deployment
+		if manifest := ctx.Path("deployments"); manifest != "" {
+			deployments, err = readDeployments(manifest, t.Contract)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, path := range ctx.StringSlice("broadcast") {
+			ds, err := readBroadcast(path, t.Contract)
+			if err != nil {
+				return err
+			}
+			deployments = append(deployments, ds...)
+		}
+
+		err = writeAddresses(t.Out, templateData.Package, t.Contract, deployments, templateData.Unexported, hdr)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ctx.IsSet("storage-layout") {
+		err = writeStorage(t.Out, storage, hdr)
+		if err != nil {
+			return err
+		}
+	}
+
+	if srcmap := ctx.Path("srcmap"); srcmap != "" {
+		err = writeSrcMap(t.Out, templateData.Package, templateData.Bin, srcmap, ctx.StringSlice("sources"), templateData.Unexported, hdr)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ctx.String("packed") != "" {
+		err = writePacked(t.Out, packed, hdr)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ctx.Bool("with-cli") {
+		err = writeCLI(t.Out, templateData, hdr)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ctx.Bool("with-http") {
+		err = writeHTTP(t.Out, templateData, hdr)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ctx.Bool("with-schema") {
+		return writeSchema(t.Out, vec)
+	}
+
+	return nil
 }
 
+// bindType returns the Go type an ABI type is bound to, or an empty string if
+// the type cannot be bound, such as tuples and arrays of them.
 func bindType(kind abi.Type) string {
 	switch kind.T {
 	case abi.AddressTy:
 		return "common.Address"
+	case abi.BoolTy:
+		return "bool"
+	case abi.StringTy:
+		return "string"
 	case abi.IntTy, abi.UintTy:
 		parts := regexp.MustCompile(`(u)?int([0-9]*)`).FindStringSubmatch(kind.String())
 		switch parts[2] {
@@ -177,13 +1088,77 @@ func bindType(kind abi.Type) string {
 			return fmt.Sprintf("%sint%s", parts[1], parts[2])
 		}
 		return "*big.Int"
-	case abi.FixedBytesTy:
+	case abi.FixedBytesTy, abi.FunctionTy:
 		return fmt.Sprintf("[%d]byte", kind.Size)
 	case abi.BytesTy:
 		return "[]byte"
-	default:
-		return kind.String()
+	case abi.SliceTy:
+		if elem := bindType(*kind.Elem); elem != "" {
+			return "[]" + elem
+		}
+	case abi.ArrayTy:
+		// Solidity reads dimensions right to left, so uint8[4][2] is an
+		// array of two uint8[4], bound to [2][4]uint8.
+		if elem := bindType(*kind.Elem); elem != "" {
+			return fmt.Sprintf("[%d]%s", kind.Size, elem)
+		}
 	}
+
+	return ""
+}
+
+// checkType reports an error if the argument name of owner has a type
+// bindType cannot bind.
+func checkType(kind abi.Type, name, owner string) error {
+	if bindType(kind) == "" {
+		if name == "" {
+			name = "an unnamed argument"
+		}
+		return fmt.Errorf("unsupported type %s of %s in %s: tuples are not bound", kind, name, owner)
+	}
+
+	return nil
+}
+
+// argType returns the Go type an argument is bound to.
+func argType(arg Argument) string {
+	if arg.Time {
+		return "time.Time"
+	}
+
+	if arg.Map != nil {
+		return arg.Map.Type
+	}
+
+	return bindType(arg.Type)
+}
+
+// bodyIdents lists the identifiers the generated function bodies refer to,
+// which their parameters must not shadow.
+var bodyIdents = map[string]bool{
+	"err":           true,
+	"values":        true,
+	"big":           true,
+	"bytes":         true,
+	"callResults":   true,
+	"convertResult": true,
+	"methodError":   true,
+	"packCall":      true,
+	"packInput":     true,
+	"packedInt":     true,
+	"packedBool":    true,
+}
+
+// paramName returns the Go parameter name of the i-th input. Inputs that are
+// unnamed, not Go identifiers or shadowing an identifier of the generated
+// body are named argN.
+func paramName(in Argument, i int) string {
+	name := argName(in, i)
+	if !token.IsIdentifier(name) || bodyIdents[name] || regexp.MustCompile(`^([bvr][0-9]+|(to|from)Big.*)$`).MatchString(name) {
+		return fmt.Sprintf("arg%d", i)
+	}
+
+	return name
 }
 
 func parseIn(in []Argument) string {
@@ -193,49 +1168,74 @@ func parseIn(in []Argument) string {
 			s += ", "
 		}
 
-		s += fmt.Sprintf("%s %s", v.Name, bindType(v.Type))
+		s += fmt.Sprintf("%s %s", paramName(v, i), argType(v))
 	}
 
 	return s
 }
 
-func parseOut(out []abi.Type) string {
-	var s string
-	if len(out) > 1 {
-		s += "("
-	}
-
+// parseOut returns the results of a generated function, named r0, r1, ...
+// and followed by the error.
+func parseOut(out []Argument) string {
+	var rets []string
 	for i, v := range out {
-		if i > 0 {
-			s += ", "
-		}
-
-		s += bindType(v)
+		rets = append(rets, fmt.Sprintf("r%d %s", i, argType(v)))
 	}
 
-	if len(out) > 1 {
-		s += ")"
+	return "(" + strings.Join(append(rets, "err error"), ", ") + ")"
+}
+
+// mapArg returns the statements converting the i-th argument v into bN when
+// it is bound with --type-map, running fail when the conversion fails.
+func mapArg(v Argument, i int, fail string) string {
+	if v.Map == nil {
+		return ""
 	}
 
-	return s
+	return fmt.Sprintf("\tb%d, err := toBig%s(%s)\n\tif err != nil {\n\t\t%s\n\t}\n", i, v.Map.Shim, v.Name, fail)
 }
 
-func parseBody(method string, input []Argument, output []abi.Type) string {
-	var data tmpFnBodyData
-	data.Method = method
+// packArg returns the argument list entry passing the i-th argument v to
+// abi.Pack, converting times and using the bN of mapArg for types bound with
+// --type-map.
+func packArg(v Argument, i int) string {
+	switch bind := bindType(v.Type); {
+	case v.Map != nil:
+		return fmt.Sprintf(", b%d", i)
+	case !v.Time:
+		return fmt.Sprintf(", %s", v.Name)
+	case bind == "*big.Int":
+		return fmt.Sprintf(", big.NewInt(%s.Unix())", v.Name)
+	default:
+		return fmt.Sprintf(", %s(%s.Unix())", bind, v.Name)
+	}
+}
 
-	for _, v := range input {
-		data.AbiPackParam += fmt.Sprintf(", %s", v.Name)
+// parseBody returns the body of a generated function.
+func parseBody(method string, input []Argument, output []Argument) string {
+	var convert, pack string
+	for i, v := range input {
+		v.Name = paramName(v, i)
+		convert += mapArg(v, i, fmt.Sprintf("err = methodError(%q, err)\n\t\treturn", method))
+		pack += packArg(v, i)
 	}
 
-	for i, v := range output {
-		if i > 0 {
-			data.Return += ", "
+	// The body is executed after the indentation of its first line.
+	data := tmpFnBodyData{Method: method, Convert: strings.TrimPrefix(convert, "\t"), AbiPackParam: pack}
+	for _, v := range output {
+		var shim string
+		if v.Map != nil {
+			shim = v.Map.Shim
 		}
-		data.Return += fmt.Sprintf("res[%d].(%s)", i, bindType(v))
+		data.Shims = append(data.Shims, shim)
 	}
 
-	tmp, err := template.New("").Parse(tmpFnBody)
+	return execBody(tmpFnBody, data)
+}
+
+// execBody executes the function body template tmpl with data.
+func execBody(tmpl string, data any) string {
+	tmp, err := template.New("").Parse(tmpl)
 	if err != nil {
 		panic(err)
 	}
@@ -248,4 +1248,3 @@ func parseBody(method string, input []Argument, output []abi.Type) string {
 
 	return s.String()
 }
-
@@ -0,0 +1,105 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestBindTypeArrays(t *testing.T) {
+	tests := []struct {
+		kind  string
+		want  string
+		value any
+	}{
+		{"bool[]", "[]bool", []bool{true, false, true}},
+		{"string[]", "[]string", []string{"a", "", "long enough to span more than one word of calldata"}},
+		{"uint8[4]", "[4]uint8", [4]uint8{1, 2, 3, 4}},
+		{"uint8[4][2]", "[2][4]uint8", [2][4]uint8{{1, 2, 3, 4}, {5, 6, 7, 8}}},
+		{"uint8[][2]", "[2][]uint8", [2][]uint8{{1}, {2, 3}}},
+		{"uint8[2][]", "[][2]uint8", [][2]uint8{{1, 2}, {3, 4}, {5, 6}}},
+		{"uint256[][]", "[][]*big.Int", [][]*big.Int{{big.NewInt(1)}, {}, {big.NewInt(2), big.NewInt(3)}}},
+		{"int24[2]", "[2]*big.Int", [2]*big.Int{big.NewInt(-1), big.NewInt(5)}},
+		{"address[2][]", "[][2]common.Address", [][2]common.Address{{common.HexToAddress("0x1"), common.HexToAddress("0x2")}}},
+		{"bytes32[3]", "[3][32]byte", [3][32]byte{{1}, {2}, {3}}},
+		{"bytes[]", "[][]byte", [][]byte{{1, 2}, {}}},
+		{"string[2][2]", "[2][2]string", [2][2]string{{"a", "b"}, {"c", "d"}}},
+		// Tuples are not bound, on their own or inside arrays.
+		{"tuple", "", nil},
+		{"tuple[]", "", nil},
+		{"tuple[2]", "", nil},
+		{"tuple[][3]", "", nil},
+	}
+
+	components := []abi.ArgumentMarshaling{{Name: "a", Type: "uint256"}, {Name: "b", Type: "address"}}
+	for _, tt := range tests {
+		var kind abi.Type
+		var err error
+		if strings.HasPrefix(tt.kind, "tuple") {
+			kind, err = abi.NewType(tt.kind, "", components)
+		} else {
+			kind, err = abi.NewType(tt.kind, "", nil)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.kind, err)
+		}
+
+		if got := bindType(kind); got != tt.want {
+			t.Errorf("bindType(%s) = %q, want %q", tt.kind, got, tt.want)
+		}
+
+		if tt.value == nil {
+			continue
+		}
+
+		// The bound Go type must be the one abi packs from and unpacks to.
+		// reflect spells byte as uint8.
+		want := strings.ReplaceAll(tt.want, "byte", "uint8")
+		if got := reflect.TypeOf(tt.value).String(); got != want {
+			t.Fatalf("%s: test value is %s, not %s", tt.kind, got, want)
+		}
+
+		args := abi.Arguments{{Type: kind}}
+		data, err := args.Pack(tt.value)
+		if err != nil {
+			t.Errorf("%s: pack: %v", tt.kind, err)
+			continue
+		}
+
+		values, err := args.Unpack(data)
+		if err != nil {
+			t.Errorf("%s: unpack: %v", tt.kind, err)
+			continue
+		}
+
+		if got := reflect.TypeOf(values[0]).String(); got != want {
+			t.Errorf("%s: unpacked %s, want %s", tt.kind, got, want)
+		}
+
+		if !reflect.DeepEqual(values[0], tt.value) {
+			t.Errorf("%s: round trip gave %v, want %v", tt.kind, values[0], tt.value)
+		}
+	}
+}
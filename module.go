@@ -0,0 +1,78 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// gethModule is the go-ethereum module the generated bindings import.
+const gethModule = "github.com/ethereum/go-ethereum"
+
+// gethVersion is the go-ethereum version required by generated modules when
+// the version evmbind was built with is unknown.
+const gethVersion = "v1.10.20"
+
+// minGoVersion is the minor version of the oldest Go release supported by
+// the go-ethereum version the bindings import.
+const minGoVersion = 17
+
+// parseGoVersion parses a Go release such as 1.18, 1.21.3 or go1.20 into its
+// minor version.
+func parseGoVersion(s string) (int, error) {
+	parts := regexp.MustCompile(`^(?:go)?1\.(\d+)(?:\.\d+)?$`).FindStringSubmatch(strings.TrimSpace(s))
+	if parts == nil {
+		return 0, fmt.Errorf("go-version: invalid Go version %q", s)
+	}
+
+	minor, _ := strconv.Atoi(parts[1])
+	if minor < minGoVersion {
+		return 0, fmt.Errorf("go-version: go-ethereum requires Go 1.%d or later", minGoVersion)
+	}
+
+	return minor, nil
+}
+
+// writeModule writes a go.mod declaring module to out, requiring the
+// go-ethereum version evmbind was built against. An existing go.mod is left
+// untouched.
+func writeModule(out, module string, goVersion int) error {
+	if module == "" || strings.ContainsAny(module, " \t\n\"'`") {
+		return fmt.Errorf("init-module: invalid module path %q", module)
+	}
+
+	path := filepath.Join(out, "go.mod")
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	geth := readBuildInfo().Geth
+	if geth == "" {
+		geth = gethVersion
+	}
+
+	mod := fmt.Sprintf("module %s\n\ngo 1.%d\n\nrequire %s %s\n", module, goVersion, gethModule, geth)
+	return ioutil.WriteFile(path, []byte(mod), 0644)
+}
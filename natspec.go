@@ -0,0 +1,181 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// natspecDoc is the userdoc or devdoc the compiler emits for a contract.
+// The userdoc holds notices and the devdoc the details, parameters and
+// return values.
+type natspecDoc struct {
+	Methods map[string]natspecEntry `json:"methods"`
+	Events  map[string]natspecEntry `json:"events"`
+}
+
+type natspecEntry struct {
+	Notice  string            `json:"notice"`
+	Details string            `json:"details"`
+	Params  map[string]string `json:"params"`
+	Returns map[string]string `json:"returns"`
+}
+
+// readNatspec reads a userdoc or devdoc either on its own or from a compiler
+// artifact carrying it under key.
+func readNatspec(path, key string) (*natspecDoc, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var artifact map[string]json.RawMessage
+	if err := json.Unmarshal(src, &artifact); err != nil {
+		return nil, err
+	}
+
+	if raw, ok := artifact[key]; ok {
+		src = raw
+	}
+
+	var doc natspecDoc
+	if err := json.Unmarshal(src, &doc); err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+// natspecOf converts a userdoc or devdoc decoded by the compiler package.
+func natspecOf(v any) (natspecDoc, error) {
+	var doc natspecDoc
+	if v == nil {
+		return doc, nil
+	}
+
+	src, err := json.Marshal(v)
+	if err != nil {
+		return doc, err
+	}
+
+	err = json.Unmarshal(src, &doc)
+	return doc, err
+}
+
+// natspecText merges the userdoc and devdoc entries of a method or event into
+// the text of its Go doc comment. The names of inputs are given in order so
+// that parameters are listed as declared.
+func natspecText(user, dev natspecEntry, inputs, outputs []Argument) string {
+	var paras []string
+	for _, text := range []string{user.Notice, dev.Details} {
+		var lines []string
+		for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+
+		if text = strings.Join(lines, "\n"); text != "" {
+			paras = append(paras, text)
+		}
+	}
+
+	if list := natspecList(dev.Params, inputs, ""); list != "" {
+		paras = append(paras, "Parameters:\n"+list)
+	}
+
+	if list := natspecList(dev.Returns, outputs, "_"); list != "" {
+		paras = append(paras, "Returns:\n"+list)
+	}
+
+	return strings.Join(paras, "\n\n")
+}
+
+// natspecList returns the list of documented args, in declaration order.
+// Unnamed args are looked up as <unnamed>N and listed without a name.
+func natspecList(docs map[string]string, args []Argument, unnamed string) string {
+	if len(docs) == 0 {
+		return ""
+	}
+
+	var names []string
+	seen, bare := make(map[string]bool), make(map[string]bool)
+	for i, arg := range args {
+		name := arg.Name
+		if name == "" {
+			if unnamed == "" {
+				continue
+			}
+			name = unnamed + strconv.Itoa(i)
+			bare[name] = true
+		}
+		names = append(names, name)
+		seen[name] = true
+	}
+
+	// Entries which match no arg, such as ones left behind by a rename,
+	// are kept at the end.
+	var rest []string
+	for name := range docs {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+
+	var lines []string
+	for _, name := range append(names, rest...) {
+		text, ok := docs[name]
+		if !ok {
+			continue
+		}
+
+		text = strings.Join(strings.Fields(text), " ")
+		if bare[name] {
+			lines = append(lines, "  - "+text)
+			continue
+		}
+		lines = append(lines, "  - "+name+": "+text)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// docLines returns text as comment lines prefixed with indent, preceded by an
+// empty comment line separating them from the summary. It returns an empty
+// string if there is no text.
+func docLines(indent, text string) string {
+	if text == "" {
+		return ""
+	}
+
+	var s strings.Builder
+	s.WriteString(indent + "//\n")
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimRight(line, " \t\r"); line == "" {
+			s.WriteString(indent + "//\n")
+			continue
+		}
+		s.WriteString(indent + "// " + line + "\n")
+	}
+
+	return s.String()
+}
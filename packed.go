@@ -0,0 +1,145 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// packedData parses the signatures of the abi.encodePacked style helpers of
+// packed.go. Signatures are separated by semicolons, e.g.
+// "Order(address maker,uint256 amount)".
+func packedData(pkg, decl string, unexported bool) (PackedData, error) {
+	data := PackedData{Package: pkg, Unexported: unexported}
+
+	re := regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*)\s*\((.*)\)\s*$`)
+	for _, sig := range strings.Split(decl, ";") {
+		if strings.TrimSpace(sig) == "" {
+			continue
+		}
+
+		parts := re.FindStringSubmatch(sig)
+		if parts == nil {
+			return PackedData{}, fmt.Errorf("packed: invalid signature %q", sig)
+		}
+
+		helper := PackedHelper{
+			Name: strings.ToUpper(string(parts[1][0])) + parts[1][1:],
+		}
+
+		var params, types, fields []string
+		if strings.TrimSpace(parts[2]) != "" {
+			fields = strings.Split(parts[2], ",")
+		}
+
+		for i, field := range fields {
+			f := strings.Fields(field)
+			if len(f) == 0 || len(f) > 2 {
+				return PackedData{}, fmt.Errorf("packed: invalid parameter %q in %s", field, parts[1])
+			}
+
+			kind, err := abi.NewType(f[0], "", nil)
+			if err != nil {
+				return PackedData{}, fmt.Errorf("packed: %v in %s", err, parts[1])
+			}
+
+			var name string
+			if len(f) == 2 {
+				name = f[1]
+			}
+			name = paramName(Argument{Name: name}, i)
+
+			enc := packedEncode(kind, name)
+			if enc == "" {
+				return PackedData{}, fmt.Errorf("packed: unsupported type %s in %s", kind, parts[1])
+			}
+
+			params = append(params, fmt.Sprintf("%s %s", name, bindType(kind)))
+			types = append(types, kind.String())
+			helper.Parts = append(helper.Parts, enc)
+		}
+
+		helper.Params = strings.Join(params, ", ")
+		helper.Sig = fmt.Sprintf("abi.encodePacked(%s)", strings.Join(types, ", "))
+		data.Helpers = append(data.Helpers, helper)
+	}
+
+	return data, nil
+}
+
+// idents returns the identifiers packed.go declares, before --unexported
+// applies.
+func (d PackedData) idents() []string {
+	names := []string{"packedInt", "packedBool"}
+	for _, h := range d.Helpers {
+		names = append(names, "Pack"+h.Name+"Packed")
+	}
+
+	return names
+}
+
+// writePacked generates packed.go in the bindings package with an
+// abi.encodePacked style helper for every signature of data.
+func writePacked(out string, data PackedData, hdr fileHeader) error {
+	fnMap := map[string]any{"ident": identFunc(data.Unexported)}
+	templ := template.Must(template.New("").Funcs(fnMap).Parse(TemplPacked))
+
+	var b bytes.Buffer
+	if err := templ.Execute(&b, data); err != nil {
+		return err
+	}
+
+	return writeGo(filepath.Join(out, "packed.go"), b.Bytes(), hdr)
+}
+
+// packedEncode returns the expression encoding name in its packed form, or an
+// empty string if the type has no packed encoding support.
+func packedEncode(kind abi.Type, name string) string {
+	switch kind.T {
+	case abi.AddressTy:
+		return fmt.Sprintf("%s.Bytes()", name)
+	case abi.UintTy:
+		if bindType(kind) != "*big.Int" {
+			return fmt.Sprintf("packedInt(new(big.Int).SetUint64(uint64(%s)), %d)", name, kind.Size/8)
+		}
+		return fmt.Sprintf("packedInt(%s, %d)", name, kind.Size/8)
+	case abi.IntTy:
+		if bindType(kind) != "*big.Int" {
+			return fmt.Sprintf("packedInt(big.NewInt(int64(%s)), %d)", name, kind.Size/8)
+		}
+		return fmt.Sprintf("packedInt(%s, %d)", name, kind.Size/8)
+	case abi.BoolTy:
+		return fmt.Sprintf("packedBool(%s)", name)
+	case abi.FixedBytesTy:
+		return fmt.Sprintf("%s[:]", name)
+	case abi.BytesTy:
+		return name
+	case abi.StringTy:
+		return fmt.Sprintf("[]byte(%s)", name)
+	default:
+		return ""
+	}
+}
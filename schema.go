@@ -0,0 +1,154 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+const schemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// writeSchema writes JSON Schema documents describing the inputs and outputs
+// of every method and the fields of every event into out/schema.
+func writeSchema(out string, vec abi.ABI) error {
+	dir := filepath.Join(out, "schema")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for name, method := range vec.Methods {
+		in := schemaObject(method.Inputs)
+		in["$schema"] = schemaDraft
+		in["title"] = method.Sig
+		if err := writeJSON(filepath.Join(dir, name+".input.json"), in); err != nil {
+			return err
+		}
+
+		res := schemaArray(method.Outputs)
+		res["$schema"] = schemaDraft
+		res["title"] = method.Sig
+		if err := writeJSON(filepath.Join(dir, name+".output.json"), res); err != nil {
+			return err
+		}
+	}
+
+	for name, event := range vec.Events {
+		ev := schemaObject(event.Inputs)
+		ev["$schema"] = schemaDraft
+		ev["title"] = event.Sig
+		if err := writeJSON(filepath.Join(dir, name+".event.json"), ev); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeJSON(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// schemaObject describes args as an object keyed by argument name.
+func schemaObject(args abi.Arguments) map[string]any {
+	props := make(map[string]any)
+	required := []string{}
+	for i, arg := range args {
+		name := arg.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+
+		props[name] = schemaType(arg.Type)
+		required = append(required, name)
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// schemaArray describes args as a fixed length array in argument order.
+func schemaArray(args abi.Arguments) map[string]any {
+	items := []any{}
+	for _, arg := range args {
+		items = append(items, schemaType(arg.Type))
+	}
+
+	return map[string]any{
+		"type":            "array",
+		"items":           items,
+		"minItems":        len(items),
+		"maxItems":        len(items),
+		"additionalItems": false,
+	}
+}
+
+// schemaType describes the JSON encoding of an ABI type. Integers are
+// decimal strings so that 256 bit values survive JSON number precision, and
+// byte values are 0x-prefixed hex strings.
+func schemaType(kind abi.Type) map[string]any {
+	switch kind.T {
+	case abi.AddressTy:
+		return map[string]any{"type": "string", "pattern": "^0x[0-9a-fA-F]{40}$"}
+	case abi.UintTy:
+		return map[string]any{"type": "string", "pattern": "^[0-9]+$", "description": kind.String()}
+	case abi.IntTy:
+		return map[string]any{"type": "string", "pattern": "^-?[0-9]+$", "description": kind.String()}
+	case abi.BoolTy:
+		return map[string]any{"type": "boolean"}
+	case abi.StringTy:
+		return map[string]any{"type": "string"}
+	case abi.BytesTy:
+		return map[string]any{"type": "string", "pattern": "^0x([0-9a-fA-F]{2})*$"}
+	case abi.FixedBytesTy, abi.FunctionTy:
+		size := kind.Size
+		if kind.T == abi.FunctionTy {
+			size = 24
+		}
+		return map[string]any{"type": "string", "pattern": fmt.Sprintf("^0x[0-9a-fA-F]{%d}$", 2*size)}
+	case abi.SliceTy:
+		return map[string]any{"type": "array", "items": schemaType(*kind.Elem)}
+	case abi.ArrayTy:
+		return map[string]any{"type": "array", "items": schemaType(*kind.Elem), "minItems": kind.Size, "maxItems": kind.Size}
+	case abi.TupleTy:
+		props := make(map[string]any)
+		required := []string{}
+		for i, elem := range kind.TupleElems {
+			props[kind.TupleRawNames[i]] = schemaType(*elem)
+			required = append(required, kind.TupleRawNames[i])
+		}
+		return map[string]any{"type": "object", "properties": props, "required": required, "additionalProperties": false}
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,344 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/compiler"
+)
+
+// solcOptions are the solc executable and the options resolving the imports
+// of compiled sources.
+type solcOptions struct {
+	// Solc is the solc executable.
+	Solc string
+	// Remappings are import remappings such as
+	// @openzeppelin=node_modules/@openzeppelin.
+	Remappings []string
+	// BasePath is the directory imports are resolved against, the working
+	// directory if empty.
+	BasePath string
+	// IncludePaths are further directories holding imported libraries.
+	IncludePaths []string
+}
+
+// args returns the solc arguments applying the options.
+func (o solcOptions) args() ([]string, error) {
+	var args, allowed []string
+	for _, r := range o.Remappings {
+		i := strings.Index(r, "=")
+		if i <= 0 || i == len(r)-1 {
+			return nil, fmt.Errorf("remap: %q is not of the form prefix=path", r)
+		}
+
+		args = append(args, r)
+		allowed = append(allowed, r[i+1:])
+	}
+
+	if len(o.IncludePaths) > 0 && o.BasePath == "" {
+		return nil, fmt.Errorf("include-path: needs --base-path")
+	}
+
+	if o.BasePath != "" {
+		args = append(args, "--base-path", o.BasePath)
+	}
+
+	for _, dir := range o.IncludePaths {
+		args = append(args, "--include-path", dir)
+		allowed = append(allowed, dir)
+	}
+
+	// Releases before 0.8.8 refuse to read remapped files outside of the
+	// working directory unless allowed explicitly.
+	if len(allowed) > 0 {
+		args = append(args, "--allow-paths", strings.Join(allowed, ","))
+	}
+
+	return args, nil
+}
+
+// roots returns the directories source unit names are resolved against.
+func (o solcOptions) roots() []string {
+	return append([]string{o.BasePath}, o.IncludePaths...)
+}
+
+// compileSolidity compiles the source file at path with solc and returns the
+// contracts of the output keyed by path:Name.
+func compileSolidity(opts solcOptions, path string) (map[string]*compiler.Contract, error) {
+	args, err := opts.args()
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := solcVersion(opts.Solc)
+	if err != nil {
+		return nil, err
+	}
+
+	if pragma := solidityPragma(src); pragma != "" {
+		ok, err := versionMatches(version, pragma)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("solc: %s is version %d.%d.%d, %s requires %s", opts.Solc, version[0], version[1], version[2], path, pragma)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	options := strings.Join(args, " ")
+	args = append(args, "--combined-json", "abi,bin,bin-runtime,srcmap-runtime,userdoc,devdoc,metadata", path)
+	cmd := exec.Command(opts.Solc, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("solc: %v\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	v := fmt.Sprintf("%d.%d.%d", version[0], version[1], version[2])
+	return compiler.ParseCombinedJSON(stdout.Bytes(), string(src), v, v, options)
+}
+
+// readCombinedJSON reads a solc --combined-json output and returns its
+// contracts keyed by path:Name.
+func readCombinedJSON(path string) (map[string]*compiler.Contract, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return compiler.ParseCombinedJSON(src, "", "", "", "")
+}
+
+// selectContracts returns the keys of the contracts matched by selector, a
+// comma separated list of names given as Name or path:Name, or as glob
+// patterns such as "*Token". Interfaces and abstract contracts have no code
+// and are skipped unless named exactly. Without a selector, the only
+// contract with code is picked, skipping, unless source is empty, the
+// dependencies compiled alongside source.
+func selectContracts(contracts map[string]*compiler.Contract, source, selector string) ([]string, error) {
+	var keys []string
+	for key := range contracts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var patterns []string
+	for _, p := range strings.Split(selector, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+
+	if len(patterns) == 0 {
+		var found []string
+		for _, key := range keys {
+			i := strings.LastIndex(key, ":")
+			if (source == "" || key[:i] == source) && contracts[key].RuntimeCode != "0x" {
+				found = append(found, key)
+			}
+		}
+
+		switch len(found) {
+		case 0:
+			return nil, fmt.Errorf("solc: no contract with code in the compiler output")
+		case 1:
+			return found, nil
+		default:
+			return nil, fmt.Errorf("solc: the compiler output holds %s, choose with --contract", strings.Join(found, ", "))
+		}
+	}
+
+	var found []string
+	for _, p := range patterns {
+		matched := false
+		for _, key := range keys {
+			name := key[strings.LastIndex(key, ":")+1:]
+			ok, err := path.Match(p, name)
+			if err != nil {
+				return nil, fmt.Errorf("contract: bad pattern %q", p)
+			}
+
+			if full, _ := path.Match(p, key); !ok && !full {
+				continue
+			}
+			matched = true
+
+			if contracts[key].RuntimeCode == "0x" {
+				if p == name || p == key {
+					return nil, fmt.Errorf("solc: %s has no code, it is an interface or abstract contract", key)
+				}
+				continue
+			}
+
+			if !contains(found, key) {
+				found = append(found, key)
+			}
+		}
+
+		if !matched {
+			return nil, fmt.Errorf("solc: no contract %s in the compiler output", p)
+		}
+	}
+
+	if len(found) == 0 {
+		return nil, fmt.Errorf("solc: no contract with code matches %s", selector)
+	}
+
+	return found, nil
+}
+
+// contains reports whether list holds s.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// solcVersion returns the version reported by solc --version.
+func solcVersion(solc string) ([3]int, error) {
+	out, err := exec.Command(solc, "--version").Output()
+	if err != nil {
+		return [3]int{}, fmt.Errorf("solc: %s --version: %v", solc, err)
+	}
+
+	parts := regexp.MustCompile(`Version: (\d+)\.(\d+)\.(\d+)`).FindSubmatch(out)
+	if parts == nil {
+		return [3]int{}, fmt.Errorf("solc: no version in the output of %s --version", solc)
+	}
+
+	var version [3]int
+	for i := range version {
+		version[i], _ = strconv.Atoi(string(parts[i+1]))
+	}
+
+	return version, nil
+}
+
+// solidityPragma returns the version constraint of the first pragma
+// solidity directive of src, or an empty string if there is none.
+func solidityPragma(src []byte) string {
+	parts := regexp.MustCompile(`(?m)^\s*pragma\s+solidity\s+([^;]+);`).FindSubmatch(src)
+	if parts == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(parts[1]))
+}
+
+// versionMatches reports whether version satisfies a pragma solidity
+// constraint such as "^0.8.4" or ">=0.6.0 <0.9.0 || 0.5.17".
+func versionMatches(version [3]int, constraint string) (bool, error) {
+	constraint = regexp.MustCompile(`(>=|<=|>|<|=|\^|~)\s+`).ReplaceAllString(constraint, "$1")
+	for _, set := range strings.Split(constraint, "||") {
+		ok := true
+		for _, c := range strings.Fields(set) {
+			match, err := comparatorMatches(version, c)
+			if err != nil {
+				return false, err
+			}
+			ok = ok && match
+		}
+
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// comparatorMatches reports whether version satisfies a single comparator.
+// Missing components of a partial version such as "0.8" match any value
+// when compared for equality and are zero otherwise.
+func comparatorMatches(version [3]int, c string) (bool, error) {
+	parts := regexp.MustCompile(`^(>=|<=|>|<|=|\^|~)?v?(\d+)(?:\.(\d+|[x*]))?(?:\.(\d+|[x*]))?$`).FindStringSubmatch(c)
+	if parts == nil {
+		return false, fmt.Errorf("solc: unsupported pragma solidity constraint %q", c)
+	}
+
+	var want [3]int
+	n := 0
+	for i := range want {
+		v, err := strconv.Atoi(parts[i+2])
+		if err != nil {
+			break
+		}
+		want[i] = v
+		n++
+	}
+
+	cmp := 0
+	for i := range want {
+		if version[i] != want[i] {
+			cmp = version[i] - want[i]
+			break
+		}
+	}
+
+	switch parts[1] {
+	case ">=":
+		return cmp >= 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "^":
+		// The leftmost non-zero component is fixed, as in ^0.8.4 matching
+		// below 0.9.0.
+		same := version[0] == want[0]
+		if want[0] == 0 {
+			same = same && version[1] == want[1]
+		}
+		return cmp >= 0 && same, nil
+	case "~":
+		if n == 1 {
+			return version[0] == want[0], nil
+		}
+		return cmp >= 0 && version[0] == want[0] && version[1] == want[1], nil
+	default:
+		for i := 0; i < n; i++ {
+			if version[i] != want[i] {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+}
@@ -0,0 +1,75 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// bindingFile is a generated file of the bindings and its template.
+type bindingFile struct {
+	name  string
+	templ string
+}
+
+// writeBindings renders the bindings to out, either as a single evm.go or,
+// with split, as <base>_types.go, <base>_events.go and <base>_caller.go.
+// Files left by the other layout are removed so that they do not redeclare
+// the same identifiers.
+func writeBindings(out, base string, split bool, fnMap map[string]any, data TemplateData, hdr fileHeader) error {
+	single := []bindingFile{{"evm.go", Templ}}
+	if data.CodecOnly {
+		single = []bindingFile{{"evm.go", TemplCodec}}
+	}
+	parts := []bindingFile{
+		{base + "_types.go", TemplTypes},
+		{base + "_events.go", TemplEvents},
+		{base + "_caller.go", TemplCaller},
+	}
+
+	files, stale := single, parts
+	if split {
+		files, stale = parts, single
+	}
+
+	for _, f := range stale {
+		err := os.Remove(filepath.Join(out, f.name))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	for _, f := range files {
+		templ := template.Must(template.New("").Funcs(fnMap).Parse(f.templ))
+
+		var b bytes.Buffer
+		if err := templ.Execute(&b, data); err != nil {
+			return err
+		}
+
+		if err := writeGo(filepath.Join(out, f.name), b.Bytes(), hdr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// readSrcMap reads a compressed solc source map, such as the srcmap-runtime
+// of combined-json, into the start offset and source index of every
+// instruction.
+func readSrcMap(path string) ([][2]int, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		entries [][2]int
+		last    [2]int
+	)
+	for i, item := range strings.Split(strings.TrimSpace(string(src)), ";") {
+		// Fields are s:l:f:j:m and an empty field repeats the previous one.
+		for j, field := range strings.SplitN(item, ":", 4) {
+			if field == "" || j == 1 || j == 3 {
+				continue
+			}
+
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("srcmap: bad entry %d %q", i, item)
+			}
+
+			if j == 0 {
+				last[0] = n
+			} else {
+				last[1] = n
+			}
+		}
+
+		entries = append(entries, last)
+	}
+
+	return entries, nil
+}
+
+// srcLines resolves the source map entries of code to a line table holding a
+// row for every offset where the file or line changes. A file of -1 marks
+// code without a source, as generated by the compiler.
+func srcLines(code []byte, entries [][2]int, sources []string) ([]SrcLine, error) {
+	texts := make([][]byte, len(sources))
+	for i, path := range sources {
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		texts[i] = src
+	}
+
+	var lines []SrcLine
+	pc := 0
+	for _, e := range entries {
+		if pc >= len(code) {
+			break
+		}
+
+		row := SrcLine{PC: pc, File: -1}
+		if e[1] >= 0 {
+			if e[1] >= len(sources) {
+				return nil, fmt.Errorf("srcmap: source index %d has no --sources entry", e[1])
+			}
+
+			text := texts[e[1]]
+			if e[0] < 0 || e[0] > len(text) {
+				return nil, fmt.Errorf("srcmap: offset %d is past the end of %s", e[0], sources[e[1]])
+			}
+
+			row.File = e[1]
+			row.Line = bytes.Count(text[:e[0]], []byte("\n")) + 1
+		}
+
+		if n := len(lines); n == 0 || lines[n-1].File != row.File || lines[n-1].Line != row.Line {
+			lines = append(lines, row)
+		}
+
+		// Skip the immediate data of PUSH1 to PUSH32.
+		op := vm.OpCode(code[pc])
+		pc++
+		if op >= vm.PUSH1 && op <= vm.PUSH32 {
+			pc += int(op-vm.PUSH1) + 1
+		}
+	}
+
+	// The code past the mapped instructions, such as the metadata, has no
+	// source.
+	if n := len(lines); pc < len(code) && (n == 0 || lines[n-1].File != -1) {
+		lines = append(lines, SrcLine{PC: pc, File: -1})
+	}
+
+	return lines, nil
+}
+
+// srcMapIdents lists the identifiers srcmap.go declares, before --unexported
+// applies.
+var srcMapIdents = []string{"SourceFiles", "sourceLines", "ResolvePC", "sourceRow", "coverageMu", "coverage", "EnableCoverage", "WriteCoverage", "coverageConfig", "coverageTracer"}
+
+// writeSrcMap generates srcmap.go in the bindings package resolving offsets
+// of the runtime code in bin to the source lines they were compiled from and
+// reporting the lines the calls cover, unexported if unexported is set.
+func writeSrcMap(out, pkg, bin, path string, sources []string, unexported bool, hdr fileHeader) error {
+	entries, err := readSrcMap(path)
+	if err != nil {
+		return err
+	}
+
+	lines, err := srcLines(common.FromHex(bin), entries, sources)
+	if err != nil {
+		return err
+	}
+
+	data := SrcMapData{
+		Package:    pkg,
+		Unexported: unexported,
+		Lines:      lines,
+	}
+	for _, path := range sources {
+		data.Files = append(data.Files, filepath.ToSlash(path))
+	}
+
+	fnMap := map[string]any{"ident": identFunc(unexported)}
+	templ := template.Must(template.New("").Funcs(fnMap).Parse(TemplSrcMap))
+
+	var b bytes.Buffer
+	if err := templ.Execute(&b, data); err != nil {
+		return err
+	}
+
+	return writeGo(filepath.Join(out, "srcmap.go"), b.Bytes(), hdr)
+}
@@ -0,0 +1,208 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/compiler"
+)
+
+// standardOutput is the output of solc --standard-json, holding the
+// contracts by source path and name.
+type standardOutput struct {
+	Errors []struct {
+		Severity         string `json:"severity"`
+		FormattedMessage string `json:"formattedMessage"`
+	} `json:"errors"`
+	Contracts map[string]map[string]struct {
+		ABI      any    `json:"abi"`
+		Metadata string `json:"metadata"`
+		Userdoc  any    `json:"userdoc"`
+		Devdoc   any    `json:"devdoc"`
+		EVM      struct {
+			Bytecode struct {
+				Object string `json:"object"`
+			} `json:"bytecode"`
+			DeployedBytecode struct {
+				Object    string `json:"object"`
+				SourceMap string `json:"sourceMap"`
+			} `json:"deployedBytecode"`
+		} `json:"evm"`
+	} `json:"contracts"`
+}
+
+// readStandardJSON reads a solc --standard-json output and returns its
+// contracts keyed by path:Name.
+func readStandardJSON(path string) (map[string]*compiler.Contract, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var output standardOutput
+	if err := json.Unmarshal(src, &output); err != nil {
+		return nil, err
+	}
+
+	for _, e := range output.Errors {
+		if e.Severity == "error" {
+			return nil, fmt.Errorf("standard-json: the compilation failed\n%s", strings.TrimSpace(e.FormattedMessage))
+		}
+	}
+
+	contracts := make(map[string]*compiler.Contract)
+	for source, byName := range output.Contracts {
+		for contract, c := range byName {
+			contracts[source+":"+contract] = &compiler.Contract{
+				Code:        "0x" + c.EVM.Bytecode.Object,
+				RuntimeCode: "0x" + c.EVM.DeployedBytecode.Object,
+				Info: compiler.ContractInfo{
+					Source:        source,
+					Language:      "Solidity",
+					SrcMapRuntime: c.EVM.DeployedBytecode.SourceMap,
+					AbiDefinition: c.ABI,
+					UserDoc:       c.Userdoc,
+					DeveloperDoc:  c.Devdoc,
+					Metadata:      c.Metadata,
+				},
+			}
+		}
+	}
+
+	return contracts, nil
+}
+
+// writeStandardInput writes the solc --standard-json input reproducing the
+// compilation of contract, as taken by verification services. The settings
+// come from the contract metadata, and the sources it names are read from
+// the first of roots holding them, unless the metadata has their content.
+func writeStandardInput(path string, contract *compiler.Contract, roots []string) error {
+	if contract.Info.Metadata == "" {
+		return fmt.Errorf("standard-json-input: the compiler output has no metadata")
+	}
+
+	var metadata struct {
+		Language string `json:"language"`
+		Sources  map[string]struct {
+			Content *string `json:"content"`
+		} `json:"sources"`
+		Settings map[string]json.RawMessage `json:"settings"`
+	}
+	if err := json.Unmarshal([]byte(contract.Info.Metadata), &metadata); err != nil {
+		return fmt.Errorf("standard-json-input: %v", err)
+	}
+
+	type source struct {
+		Content string `json:"content"`
+	}
+	input := struct {
+		Language string                     `json:"language"`
+		Sources  map[string]source          `json:"sources"`
+		Settings map[string]json.RawMessage `json:"settings"`
+	}{
+		Language: metadata.Language,
+		Sources:  make(map[string]source),
+		Settings: make(map[string]json.RawMessage),
+	}
+
+	for name, s := range metadata.Sources {
+		if s.Content != nil {
+			input.Sources[name] = source{*s.Content}
+			continue
+		}
+
+		content, err := readSource(name, roots)
+		if err != nil {
+			return err
+		}
+		input.Sources[name] = source{string(content)}
+	}
+
+	// The compilation target is not a setting solc accepts, and the
+	// libraries are keyed by path:Name rather than nested by path.
+	for key, value := range metadata.Settings {
+		switch key {
+		case "compilationTarget":
+		case "libraries":
+			var flat map[string]string
+			if err := json.Unmarshal(value, &flat); err != nil {
+				return fmt.Errorf("standard-json-input: %v", err)
+			}
+
+			libraries := make(map[string]map[string]string)
+			for name, addr := range flat {
+				source, lib := "", name
+				if i := strings.LastIndex(name, ":"); i >= 0 {
+					source, lib = name[:i], name[i+1:]
+				}
+
+				if libraries[source] == nil {
+					libraries[source] = make(map[string]string)
+				}
+				libraries[source][lib] = addr
+			}
+
+			nested, err := json.Marshal(libraries)
+			if err != nil {
+				return err
+			}
+			input.Settings[key] = nested
+		default:
+			input.Settings[key] = value
+		}
+	}
+	input.Settings["outputSelection"] = json.RawMessage(`{"*":{"*":["abi","evm.bytecode","evm.deployedBytecode","metadata"]}}`)
+
+	src, err := json.MarshalIndent(input, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(src, '\n'), 0644)
+}
+
+// readSource reads the file of a source unit name, whose imports are already
+// remapped, from the first of roots holding it. An empty root is the working
+// directory.
+func readSource(name string, roots []string) ([]byte, error) {
+	var dirs []string
+	for _, root := range roots {
+		if root == "" {
+			root = "."
+		}
+		dirs = append(dirs, root)
+
+		content, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err == nil {
+			return content, nil
+		}
+
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("standard-json-input: no file for source %s under %s", name, strings.Join(dirs, ", "))
+}
@@ -0,0 +1,280 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// storageLayout is the storage layout emitted by solc --storage-layout.
+type storageLayout struct {
+	Storage []struct {
+		Contract string `json:"contract"`
+		Label    string `json:"label"`
+		Offset   int    `json:"offset"`
+		Slot     string `json:"slot"`
+		Type     string `json:"type"`
+	} `json:"storage"`
+	Types map[string]storageType `json:"types"`
+}
+
+type storageType struct {
+	Encoding      string `json:"encoding"`
+	Label         string `json:"label"`
+	NumberOfBytes string `json:"numberOfBytes"`
+	Key           string `json:"key"`
+	Value         string `json:"value"`
+	Base          string `json:"base"`
+	Members       []any  `json:"members"`
+}
+
+// size returns the number of bytes taken by the type.
+func (t storageType) size() int {
+	n, _ := strconv.Atoi(t.NumberOfBytes)
+	return n
+}
+
+// readStorageLayout reads a storage layout either on its own or from a
+// compiler artifact carrying it under storageLayout.
+func readStorageLayout(path string) (*storageLayout, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var artifact struct {
+		StorageLayout *storageLayout `json:"storageLayout"`
+	}
+	if err := json.Unmarshal(src, &artifact); err != nil {
+		return nil, err
+	}
+
+	if artifact.StorageLayout != nil {
+		return artifact.StorageLayout, nil
+	}
+
+	var layout storageLayout
+	if err := json.Unmarshal(src, &layout); err != nil {
+		return nil, err
+	}
+
+	return &layout, nil
+}
+
+// storageIdents lists the identifiers storage.go declares besides the
+// accessors of each state variable.
+var storageIdents = []string{"StorageReader", "MappingSlot", "AddressKey", "IntKey", "BoolKey", "FixedBytesKey", "ArraySlot", "StaticArraySlot", "OffsetSlot", "StorageValue", "signedWord"}
+
+// storageData reads the storage layout at path into the data of storage.go.
+func storageData(pkg, path string, unexported bool) (StorageData, error) {
+	layout, err := readStorageLayout(path)
+	if err != nil {
+		return StorageData{}, err
+	}
+
+	data := StorageData{Package: pkg, Unexported: unexported}
+	for _, v := range layout.Storage {
+		sv, err := storageVar(layout, identFunc(unexported), v.Label, v.Slot, v.Offset, v.Type)
+		if err != nil {
+			return StorageData{}, err
+		}
+
+		data.Vars = append(data.Vars, sv)
+	}
+
+	return data, nil
+}
+
+// idents returns the identifiers storage.go declares, before --unexported
+// applies.
+func (d StorageData) idents() []string {
+	names := append([]string{}, storageIdents...)
+	for _, v := range d.Vars {
+		names = append(names, "StorageSlot"+v.Name, "storage"+v.Name, "Read"+v.Name+"At")
+	}
+
+	return names
+}
+
+// writeStorage generates storage.go in the bindings package with slot
+// accessors for every state variable of data.
+func writeStorage(out string, data StorageData, hdr fileHeader) error {
+	fnMap := map[string]any{"ident": identFunc(data.Unexported)}
+	templ := template.Must(template.New("").Funcs(fnMap).Parse(TemplStorage))
+
+	var b bytes.Buffer
+	if err := templ.Execute(&b, data); err != nil {
+		return err
+	}
+
+	return writeGo(filepath.Join(out, "storage.go"), b.Bytes(), hdr)
+}
+
+// storageVar walks the mappings and dynamic arrays leading to the value of a
+// state variable, collecting the accessor parameters and slot math. ident
+// names the slot helpers.
+func storageVar(layout *storageLayout, ident func(string) string, label, slot string, offset int, id string) (StorageVar, error) {
+	base, ok := new(big.Int).SetString(slot, 10)
+	if !ok {
+		return StorageVar{}, fmt.Errorf("storage-layout: invalid slot %q of %s", slot, label)
+	}
+
+	sv := StorageVar{
+		Name:   strings.ToUpper(string(label[0])) + label[1:],
+		Label:  label,
+		Type:   layout.Types[id].Label,
+		Slot:   fmt.Sprintf("0x%064x", base),
+		Offset: offset,
+	}
+
+	var params, args []string
+	for i := 0; ; i++ {
+		t, ok := layout.Types[id]
+		if !ok {
+			return StorageVar{}, fmt.Errorf("storage-layout: unknown type %s of %s", id, label)
+		}
+
+		switch t.Encoding {
+		case "mapping":
+			key := layout.Types[t.Key]
+			kind, err := storageKind(key.Label)
+			if err != nil {
+				return StorageVar{}, fmt.Errorf("storage-layout: %s key of %s: %v", key.Label, label, err)
+			}
+
+			name := fmt.Sprintf("key%d", i)
+			params = append(params, fmt.Sprintf("%s %s", name, bindType(kind)))
+			args = append(args, name)
+			sv.Path = append(sv.Path, fmt.Sprintf("slot, off = %s(%s, slot), 0", ident("MappingSlot"), storageKey(kind, name, ident)))
+			id = t.Value
+			continue
+		case "dynamic_array":
+			name := fmt.Sprintf("index%d", i)
+			params = append(params, name+" uint64")
+			args = append(args, name)
+			sv.Path = append(sv.Path, fmt.Sprintf("slot, off = %s(slot, %s, %d)", ident("ArraySlot"), name, layout.Types[t.Base].size()))
+			id = t.Base
+			continue
+		case "inplace":
+			if t.Base == "" {
+				break
+			}
+
+			name := fmt.Sprintf("index%d", i)
+			params = append(params, name+" uint64")
+			args = append(args, name)
+			sv.Path = append(sv.Path, fmt.Sprintf("slot, off = %s(slot, %s, %d)", ident("StaticArraySlot"), name, layout.Types[t.Base].size()))
+			id = t.Base
+			continue
+		}
+
+		if t.Encoding == "inplace" && t.Members == nil && t.Base == "" && t.size() <= 32 {
+			if kind, err := storageKind(t.Label); err == nil {
+				sv.GoType = bindType(kind)
+				sv.Size = t.size()
+				sv.Decode = storageDecode(kind)
+			}
+		}
+		break
+	}
+
+	sv.Params = strings.Join(params, ", ")
+	sv.Args = strings.Join(args, ", ")
+	return sv, nil
+}
+
+// storageKind resolves the ABI type of an elementary storage type label.
+func storageKind(label string) (abi.Type, error) {
+	switch {
+	case label == "address payable", strings.HasPrefix(label, "contract "):
+		label = "address"
+	case strings.HasPrefix(label, "enum "):
+		label = "uint8"
+	}
+
+	return abi.NewType(label, "", nil)
+}
+
+// storageKey returns the expression encoding a mapping key for hashing, with
+// the key helpers named by ident.
+func storageKey(kind abi.Type, name string, ident func(string) string) string {
+	switch kind.T {
+	case abi.AddressTy:
+		return fmt.Sprintf("%s(%s)", ident("AddressKey"), name)
+	case abi.BoolTy:
+		return fmt.Sprintf("%s(%s)", ident("BoolKey"), name)
+	case abi.UintTy:
+		if bindType(kind) != "*big.Int" {
+			return fmt.Sprintf("%s(new(big.Int).SetUint64(uint64(%s)))", ident("IntKey"), name)
+		}
+		return fmt.Sprintf("%s(%s)", ident("IntKey"), name)
+	case abi.IntTy:
+		if bindType(kind) != "*big.Int" {
+			return fmt.Sprintf("%s(big.NewInt(int64(%s)))", ident("IntKey"), name)
+		}
+		return fmt.Sprintf("%s(%s)", ident("IntKey"), name)
+	case abi.FixedBytesTy:
+		return fmt.Sprintf("%s(%s[:])", ident("FixedBytesKey"), name)
+	case abi.StringTy:
+		return fmt.Sprintf("[]byte(%s)", name)
+	default:
+		return name
+	}
+}
+
+// storageDecode returns the expression decoding the value bytes b.
+func storageDecode(kind abi.Type) string {
+	bind := bindType(kind)
+	switch kind.T {
+	case abi.AddressTy:
+		return "common.BytesToAddress(b)"
+	case abi.BoolTy:
+		return "b[0] != 0"
+	case abi.UintTy:
+		switch bind {
+		case "*big.Int":
+			return "new(big.Int).SetBytes(b)"
+		case "uint64":
+			return "new(big.Int).SetBytes(b).Uint64()"
+		}
+		return fmt.Sprintf("%s(new(big.Int).SetBytes(b).Uint64())", bind)
+	case abi.IntTy:
+		switch bind {
+		case "*big.Int":
+			return "signedWord(b)"
+		case "int64":
+			return "signedWord(b).Int64()"
+		}
+		return fmt.Sprintf("%s(signedWord(b).Int64())", bind)
+	case abi.FixedBytesTy:
+		return fmt.Sprintf("*(*%s)(b)", bind)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,142 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStorageVar(t *testing.T) {
+	layout := &storageLayout{Types: map[string]storageType{
+		"t_uint256":                      {Encoding: "inplace", Label: "uint256", NumberOfBytes: "32"},
+		"t_uint128":                      {Encoding: "inplace", Label: "uint128", NumberOfBytes: "16"},
+		"t_uint8":                        {Encoding: "inplace", Label: "uint8", NumberOfBytes: "1"},
+		"t_bool":                         {Encoding: "inplace", Label: "bool", NumberOfBytes: "1"},
+		"t_address":                      {Encoding: "inplace", Label: "address", NumberOfBytes: "20"},
+		"t_string_storage":               {Encoding: "bytes", Label: "string", NumberOfBytes: "32"},
+		"t_array(t_uint128)dyn_storage":  {Encoding: "dynamic_array", Label: "uint128[]", NumberOfBytes: "32", Base: "t_uint128"},
+		"t_array(t_uint8)3_storage":      {Encoding: "inplace", Label: "uint8[3]", NumberOfBytes: "32", Base: "t_uint8"},
+		"t_mapping(t_address,t_uint256)": {Encoding: "mapping", Label: "mapping(address => uint256)", NumberOfBytes: "32", Key: "t_address", Value: "t_uint256"},
+		"t_mapping(t_address,t_bool)":    {Encoding: "mapping", Label: "mapping(address => bool)", NumberOfBytes: "32", Key: "t_address", Value: "t_bool"},
+		"t_mapping(t_uint256,t_mapping(t_address,t_bool))": {Encoding: "mapping", Label: "mapping(uint256 => mapping(address => bool))", NumberOfBytes: "32", Key: "t_uint256", Value: "t_mapping(t_address,t_bool)"},
+		"t_mapping(t_string_memory_ptr,t_uint8)":           {Encoding: "mapping", Label: "mapping(string => uint8)", NumberOfBytes: "32", Key: "t_string_memory_ptr", Value: "t_uint8"},
+		"t_string_memory_ptr":                              {Encoding: "bytes", Label: "string", NumberOfBytes: "32"},
+	}}
+
+	tests := []struct {
+		label, slot string
+		offset      int
+		id          string
+		unexported  bool
+
+		want StorageVar
+	}{
+		{
+			label: "total", slot: "2", id: "t_uint256",
+			want: StorageVar{Slot: "0x0000000000000000000000000000000000000000000000000000000000000002", GoType: "*big.Int", Size: 32},
+		},
+		{
+			label: "owner", slot: "1", offset: 12, id: "t_address",
+			want: StorageVar{Slot: "0x0000000000000000000000000000000000000000000000000000000000000001", Offset: 12, GoType: "common.Address", Size: 20},
+		},
+		{
+			label: "name", slot: "255", id: "t_string_storage",
+			want: StorageVar{Slot: "0x00000000000000000000000000000000000000000000000000000000000000ff"},
+		},
+		{
+			label: "balances", slot: "3", id: "t_mapping(t_address,t_uint256)",
+			want: StorageVar{
+				Slot:   "0x0000000000000000000000000000000000000000000000000000000000000003",
+				Params: "key0 common.Address",
+				Args:   "key0",
+				Path:   []string{"slot, off = MappingSlot(AddressKey(key0), slot), 0"},
+				GoType: "*big.Int",
+				Size:   32,
+			},
+		},
+		{
+			label: "allowed", slot: "4", id: "t_mapping(t_uint256,t_mapping(t_address,t_bool))", unexported: true,
+			want: StorageVar{
+				Slot:   "0x0000000000000000000000000000000000000000000000000000000000000004",
+				Params: "key0 *big.Int, key1 common.Address",
+				Args:   "key0, key1",
+				Path: []string{
+					"slot, off = mappingSlot(intKey(key0), slot), 0",
+					"slot, off = mappingSlot(addressKey(key1), slot), 0",
+				},
+				GoType: "bool",
+				Size:   1,
+			},
+		},
+		{
+			label: "levels", slot: "5", id: "t_mapping(t_string_memory_ptr,t_uint8)",
+			want: StorageVar{
+				Slot:   "0x0000000000000000000000000000000000000000000000000000000000000005",
+				Params: "key0 string",
+				Args:   "key0",
+				Path:   []string{"slot, off = MappingSlot([]byte(key0), slot), 0"},
+				GoType: "uint8",
+				Size:   1,
+			},
+		},
+		{
+			label: "amounts", slot: "6", id: "t_array(t_uint128)dyn_storage",
+			want: StorageVar{
+				Slot:   "0x0000000000000000000000000000000000000000000000000000000000000006",
+				Params: "index0 uint64",
+				Args:   "index0",
+				Path:   []string{"slot, off = ArraySlot(slot, index0, 16)"},
+				GoType: "*big.Int",
+				Size:   16,
+			},
+		},
+		{
+			label: "flags", slot: "7", id: "t_array(t_uint8)3_storage",
+			want: StorageVar{
+				Slot:   "0x0000000000000000000000000000000000000000000000000000000000000007",
+				Params: "index0 uint64",
+				Args:   "index0",
+				Path:   []string{"slot, off = StaticArraySlot(slot, index0, 1)"},
+				GoType: "uint8",
+				Size:   1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := storageVar(layout, identFunc(tt.unexported), tt.label, tt.slot, tt.offset, tt.id)
+		if err != nil {
+			t.Errorf("%s: %v", tt.label, err)
+			continue
+		}
+
+		// Only the placement, the parameters and the slot math are compared.
+		got.Name, got.Label, got.Type, got.Decode = "", "", "", ""
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: storageVar = %+v, want %+v", tt.label, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ slot, id string }{{"0x1", "t_uint256"}, {"1", "t_missing"}} {
+		if _, err := storageVar(layout, identFunc(false), "bad", tt.slot, 0, tt.id); err == nil {
+			t.Errorf("storageVar(slot %s, type %s) succeeded, want an error", tt.slot, tt.id)
+		}
+	}
+}
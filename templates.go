@@ -24,12 +24,69 @@ import "github.com/ethereum/go-ethereum/accounts/abi"
 type TemplateData struct {
 	// package name
 	Package string
-	// ABI is the ABI of the contract.
+	// ABI is the minified ABI of the contract as a Go string literal.
 	ABI string
 	// Bin is the compiled bytecode of the contract.
 	Bin string
 	// Funcs is a list of functions.
 	Funcs []Function
+	// Decimals is the name of the function reading the token decimals, set
+	// when the contract exposes decimals().
+	Decimals string
+	// DecimalsBig reports whether decimals() is bound to *big.Int.
+	DecimalsBig bool
+	// Calls reports whether a <Method>Call type is generated per method.
+	Calls bool
+	// CodecOnly reports whether only the packing and unpacking functions
+	// are generated.
+	CodecOnly bool
+	// GoVersion is the minor version of the oldest Go release the code
+	// must build with.
+	GoVersion int
+	// Any is the spelling of the empty interface for GoVersion.
+	Any string
+	// Unexported reports whether the bindings are generated with unexported
+	// names.
+	Unexported bool
+	// Coverage reports whether the calls are traced for the coverage helpers
+	// of srcmap.go.
+	Coverage bool
+	// TypeMaps is the list of types bound with --type-map, ordered by type.
+	TypeMaps []TypeMap
+	// Events is a list of events.
+	Events []Event
+	// EventHandler is the name of the interface DispatchLog routes the
+	// logs to, set when the codec has events with a topic.
+	EventHandler string
+	// Compress reports whether the code is embedded gzip compressed.
+	Compress bool
+	// BinGzip is the base64 encoded, gzip compressed code, set when it is
+	// compressed but not embedded from a file.
+	BinGzip string
+	// Embed is the base name of the ABI and bytecode files embedded with
+	// go:embed in place of the ABI and Bin literals, if set.
+	Embed string
+	// Contract is the name of the contract reported by errors, the package
+	// name when it is not known.
+	Contract string
+}
+
+// Event is an event of the contract.
+type Event struct {
+	// Name is the name of the event.
+	Name string
+	// Sig is the signature of the event.
+	Sig string
+	// Topic is the topic hash of the event.
+	Topic string
+	// ABIName is the name of the event in the ABI.
+	ABIName string
+	// Inputs is a list of the event inputs.
+	Inputs []Argument
+	// Anonymous reports whether the event is logged without its topic.
+	Anonymous bool
+	// Doc is the NatSpec documentation of the event, if any.
+	Doc string
 }
 
 // Function is a function.
@@ -42,10 +99,16 @@ type Function struct {
 	Id string
 	// Raw is the raw ABI of the function.
 	Raw string
+	// Sig is the signature of the function.
+	Sig string
+	// Constant reports whether the function is view or pure.
+	Constant bool
 	// Inputs is a list of inputs.
 	Inputs []Argument
 	// Outputs is a list of outputs.
-	Outputs []abi.Type
+	Outputs []Argument
+	// Doc is the NatSpec documentation of the method, if any.
+	Doc string
 }
 
 // Argument is an argument of the function.
@@ -54,67 +117,1694 @@ type Argument struct {
 	Name string
 	// Type is the type of the argument.
 	Type abi.Type
+	// Time reports whether the argument is bound as time.Time.
+	Time bool
+	// Map is the Go type given with --type-map replacing *big.Int, if any.
+	Map *TypeMap
+	// Indexed reports whether the event input is stored in a topic.
+	Indexed bool
+}
+
+// TypeMap is a user-defined Go type bound in place of *big.Int.
+type TypeMap struct {
+	// Import is the import path of the package declaring the type.
+	Import string
+	// Package is the name the package is imported as.
+	Package string
+	// Type is the qualified Go type, such as decimal.Decimal.
+	Type string
+	// Shim is the type name the conversion shims are named after.
+	Shim string
 }
 
 var Templ = `// Code generated by evmbind. DO NOT EDIT.
 package {{ .Package }}
 
 import (
+	"bytes"
+{{- if .Compress }}
+	"compress/gzip"
+{{- end }}
+	"context"
+{{- if .Embed }}
+	_ "embed"
+{{- end }}
+{{- if and .Compress (not .Embed) }}
+	"encoding/base64"
+{{- end }}
+	"errors"
+	"fmt"
+{{- if .Compress }}
+	"io"
+{{- end }}
 	"math/big"
+	"reflect"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/crypto"
+{{- range .TypeMaps }}
+	{{ .Package }} "{{ .Import }}"
+{{- end }}
 )
 
 var (
 	_ = big.NewInt
+	_ = time.Unix
+)
+
+` + tmpTypes + tmpEvents + tmpCaller
+
+// TemplTypes is the template of the file holding the contract code and the
+// helpers shared by the calls when the output is split.
+var TemplTypes = `// Code generated by evmbind. DO NOT EDIT.
+package {{ .Package }}
+
+import (
+	"bytes"
+{{- if .Compress }}
+	"compress/gzip"
+{{- end }}
+	"context"
+{{- if .Embed }}
+	_ "embed"
+{{- end }}
+{{- if and .Compress (not .Embed) }}
+	"encoding/base64"
+{{- end }}
+	"errors"
+	"fmt"
+{{- if .Compress }}
+	"io"
+{{- end }}
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/crypto"
+{{- range .TypeMaps }}
+	{{ .Package }} "{{ .Import }}"
+{{- end }}
+)
+
+` + tmpTypes
+
+// TemplEvents is the template of the file holding the event topics when the
+// output is split.
+var TemplEvents = `// Code generated by evmbind. DO NOT EDIT.
+package {{ .Package }}
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+` + tmpEvents
+
+// TemplCaller is the template of the file holding the contract methods when
+// the output is split.
+var TemplCaller = `// Code generated by evmbind. DO NOT EDIT.
+package {{ .Package }}
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+{{- range .TypeMaps }}
+	{{ .Package }} "{{ .Import }}"
+{{- end }}
 )
 
 var (
-	ABI = "{{ .ABI }}"
+	_ = big.NewInt
+	_ = time.Unix
+	_ = common.Address{}
+)
+
+` + tmpCaller
+
+var tmpTypes = `{{ if .Embed }}// ABI is read from the ABI file next to the bindings.
+//
+//go:embed {{ .Embed }}.abi
+var ABI string
+{{ if .Compress }}
+// binGzip is the gzip compressed code, read from the file next to the
+// bindings.
+//
+//go:embed {{ .Embed }}.bin.gz
+var binGzip string
+{{ else }}
+// Bin is read from the bytecode file next to the bindings.
+//
+//go:embed {{ .Embed }}.bin
+var Bin string
+{{ end }}{{ else if .Compress }}var ABI = {{ .ABI }}
+
+// binGzip is the base64 encoded, gzip compressed code.
+var binGzip = "{{ .BinGzip }}"
+{{ else }}var (
+	ABI = {{ .ABI }}
 	Bin = "{{ .Bin }}"
 )
+{{ end }}{{ if .Compress }}
+var (
+	binOnce sync.Once
+	binCode []byte
+)
+
+// runtimeCode returns the code of the contract, decompressed on first use.
+func runtimeCode() []byte {
+	binOnce.Do(func() {
+		var src io.Reader = strings.NewReader(binGzip)
+{{- if not .Embed }}
+		src = base64.NewDecoder(base64.StdEncoding, src)
+{{- end }}
+
+		r, err := gzip.NewReader(src)
+		if err != nil {
+			panic(err)
+		}
+
+		binCode, err = io.ReadAll(r)
+		if err != nil {
+			panic(err)
+		}
+	})
+
+	return binCode
+}
+
+// Bin returns the hex encoded code of the contract.
+func Bin() string {
+	return common.Bytes2Hex(runtimeCode())
+}
+{{ else }}
+// runtimeCode returns the code of the contract.
+func runtimeCode() []byte {
+	return common.Hex2Bytes(Bin)
+}
+{{ end }}
+// {{ ident "ContractMetaData" }} holds the ABI and code of a contract and parses the ABI
+// once, on first use.
+type {{ ident "ContractMetaData" }} struct {
+	// ABI is the ABI JSON of the contract.
+	ABI string
+	// Bin is the hex encoded code of the contract, empty when the code is
+	// compressed and read with Bin().
+	Bin string
+
+	once   sync.Once
+	parsed *abi.ABI
+	err    error
+}
+
+// GetAbi returns the parsed ABI, parsing it on the first call.
+func (m *{{ ident "ContractMetaData" }}) GetAbi() (*abi.ABI, error) {
+	m.once.Do(func() {
+		parsed, err := abi.JSON(strings.NewReader(m.ABI))
+		m.parsed, m.err = &parsed, err
+	})
+
+	return m.parsed, m.err
+}
+
+// {{ ident "MetaData" }} holds the ABI and code of the contract.
+var {{ ident "MetaData" }} = &{{ ident "ContractMetaData" }}{
+	ABI: ABI,
+{{- if not .Compress }}
+	Bin: Bin,
+{{- end }}
+}
+
+// parsedABI returns the ABI parsed once by {{ ident "MetaData" }}.
+func parsedABI() (*abi.ABI, error) {
+	return {{ ident "MetaData" }}.GetAbi()
+}
+
+` + tmpErrors + `// {{ ident "ContractAddress" }} is the address the contract executes at.
+var {{ ident "ContractAddress" }} = common.BytesToAddress([]byte("contract"))
+
+var (
+	blockMu sync.Mutex
+	// block is the block context and sender of the calls. A nil Time runs
+	// them at the current time.
+	block = runtime.Config{BlockNumber: new(big.Int)}
+	// chain is the state the calls start from, holding the contract and the
+	// accounts set up with the cheatcodes. Calls run on a copy of it, so
+	// their own writes are discarded.
+	chain *state.StateDB
+)
+
+// chainState returns chain, creating it with the contract code on first use.
+// blockMu must be held.
+func chainState() *state.StateDB {
+	if chain == nil {
+		chain, _ = state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		chain.CreateAccount({{ ident "ContractAddress" }})
+		chain.SetCode({{ ident "ContractAddress" }}, runtimeCode())
+	}
+
+	return chain
+}
+
+// {{ ident "Prank" }} makes sender the msg.sender and tx.origin of the following calls.
+func {{ ident "Prank" }}(sender common.Address) {
+	blockMu.Lock()
+	defer blockMu.Unlock()
+
+	block.Origin = sender
+}
+
+// {{ ident "StopPrank" }} restores the zero address as the sender of the calls.
+func {{ ident "StopPrank" }}() {
+	{{ ident "Prank" }}(common.Address{})
+}
+
+// {{ ident "SetBalance" }} sets the balance of account for the following calls.
+func {{ ident "SetBalance" }}(account common.Address, balance *big.Int) {
+	blockMu.Lock()
+	defer blockMu.Unlock()
+
+	chainState().SetBalance(account, balance)
+}
+
+// {{ ident "SetStorageAt" }} sets the storage slot key of account for the following
+// calls. Slots of the contract are read from {{ ident "ContractAddress" }}.
+func {{ ident "SetStorageAt" }}(account common.Address, key, value common.Hash) {
+	blockMu.Lock()
+	defer blockMu.Unlock()
+
+	chainState().SetState(account, key, value)
+}
+
+// {{ ident "SetCode" }} sets the code of account for the following calls, such as a
+// mock the contract calls into. Setting the code of {{ ident "ContractAddress" }}
+// replaces the contract.
+func {{ ident "SetCode" }}(account common.Address, code []byte) {
+	blockMu.Lock()
+	defer blockMu.Unlock()
+
+	chainState().SetCode(account, code)
+}
+
+// {{ ident "WarpTime" }} sets the block timestamp the calls execute at.
+func {{ ident "WarpTime" }}(t time.Time) {
+	blockMu.Lock()
+	defer blockMu.Unlock()
+
+	block.Time = big.NewInt(t.Unix())
+}
+
+// {{ ident "MineBlocks" }} advances the block number the calls execute at by n.
+func {{ ident "MineBlocks" }}(n uint64) {
+	blockMu.Lock()
+	defer blockMu.Unlock()
+
+	block.BlockNumber = new(big.Int).Add(block.BlockNumber, new(big.Int).SetUint64(n))
+}
+
+// exec executes the given contract and method inside evm. A revert is
+// reported as ErrExecutionReverted, with its reason if the return data holds
+// one.
+func exec(inputs []byte) ([]byte, error) {
+	code := runtimeCode()
+	if len(code) == 0 {
+		return nil, {{ ident "ErrNoCode" }}
+	}
 
-// exec executes the given contract and method inside evm.
-func exec(inputs []byte) []byte {
-	code := common.Hex2Bytes(Bin)
-	ret, _, err := runtime.Execute(code, inputs, nil)
+	blockMu.Lock()
+	cfg := runtime.Config{BlockNumber: block.BlockNumber, Time: block.Time, Origin: block.Origin, State: chainState().Copy()}
+	blockMu.Unlock()
+{{- if .Coverage }}
+	cfg.EVMConfig = coverageConfig()
+{{- end }}
+
+	ret, _, err := runtime.Call({{ ident "ContractAddress" }}, inputs, &cfg)
+	if errors.Is(err, vm.ErrExecutionReverted) {
+		if reason, err := abi.UnpackRevert(ret); err == nil {
+			return ret, fmt.Errorf("%w: %s", {{ ident "ErrExecutionReverted" }}, reason)
+		}
+		return ret, {{ ident "ErrExecutionReverted" }}
+	}
+
+	return ret, err
+}
+
+// {{ ident "RawCall" }} executes calldata inside evm and returns the raw return data.
+// It reaches methods missing from ABI, such as ones added by an upgrade.
+func {{ ident "RawCall" }}(calldata []byte) ([]byte, error) {
+	return exec(calldata)
+}
+
+// callResults executes method with args inside evm and returns its unpacked
+// results. Its errors are *{{ ident "CallError" }} values.
+func callResults(method string, args ...{{ .Any }}) ([]{{ .Any }}, error) {
+	inputs, err := packCall(method, args...)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	ret, err := exec(inputs)
+	if err != nil {
+		return nil, methodError(method, err)
+	}
+
+	abis, err := parsedABI()
+	if err != nil {
+		return nil, methodError(method, err)
+	}
+
+	res, err := abis.Unpack(method, ret)
+	if err != nil {
+		return nil, methodError(method, fmt.Errorf("%w: %v", {{ ident "ErrDecode" }}, err))
 	}
 
-	return ret
+	return res, nil
 }
 
-{{range .Funcs}}// {{ .Name }} is a function represented contract method {{ .Id }}.
-//
-// Solidity: {{ .Raw }}
-func {{ .Name }}({{$params := parseIn .Inputs}}{{ $params }}) {{$output := parseOut .Outputs}}{{ $output }} {
-	{{$body := parseBody .Method .Inputs .Outputs}}{{ $body }}
+// packCall packs the calldata of method with args. The panics of abi.Pack,
+// such as on nil *big.Int arguments, are reported as errors.
+func packCall(method string, args ...{{ .Any }}) (inputs []byte, err error) {
+	abis, err := parsedABI()
+	if err != nil {
+		return nil, methodError(method, err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			inputs, err = nil, methodError(method, fmt.Errorf("%v", r))
+		}
+	}()
+
+	inputs, err = abis.Pack(method, args...)
+	if err != nil {
+		return nil, methodError(method, err)
+	}
+
+	return inputs, nil
 }
 
-{{ end }}`
+` + tmpResult + `{{ if ge .GoVersion 18 }}
+// {{ ident "Call" }} executes method with args inside evm and returns its first result
+// as a T. It reaches methods through the embedded ABI without a generated
+// function, and reports unexpected result types as errors.
+func {{ ident "Call" }}[T any](method string, args ...any) (T, error) {
+	var v T
+	res, err := callResults(method, args...)
+	if err != nil {
+		return v, err
+	}
 
-type tmpFnBodyData struct {
-	Method       string
-	AbiPackParam string
-	Return       string
+	v, err = result[T](res, 0)
+	if err != nil {
+		return v, methodError(method, err)
+	}
+
+	return v, nil
+}
+{{ end }}
+// {{ ident "CodeReader" }} reads deployed contract code. It is implemented by
+// ethclient.Client.
+type {{ ident "CodeReader" }} interface {
+	CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error)
+}
+
+// {{ ident "CodeReport" }} describes how deployed code compares with Bin.
+type {{ ident "CodeReport" }} struct {
+	// Match reports whether the code matches, ignoring the metadata trailer.
+	Match bool
+	// MetadataMatch reports whether the metadata trailers are identical too.
+	MetadataMatch bool
+	// DeployedSize and ExpectedSize are the code sizes without metadata.
+	DeployedSize, ExpectedSize int
+	// Mismatch is the offset of the first differing byte, or -1.
+	Mismatch int
 }
 
-var tmpFnBody = `abis, err := abi.JSON(strings.NewReader(ABI))
+// {{ ident "VerifyDeployed" }} fetches the code deployed at contract and compares it with
+// Bin. The CBOR metadata trailer, which differs between otherwise identical
+// builds, is compared separately. For libraries the address embedded by the
+// call guard is ignored.
+func {{ ident "VerifyDeployed" }}(ctx context.Context, backend {{ ident "CodeReader" }}, contract common.Address) (*{{ ident "CodeReport" }}, error) {
+	code, err := backend.CodeAt(ctx, contract, nil)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("reading code at %s: %w", contract, err)
+	}
+
+	if len(code) == 0 {
+		return nil, fmt.Errorf("%w at %s", {{ ident "ErrNoCode" }}, contract)
+	}
+
+	got, gotMeta := stripMetadata(code)
+	want, wantMeta := stripMetadata(runtimeCode())
+
+	report := &{{ ident "CodeReport" }}{
+		DeployedSize:  len(got),
+		ExpectedSize:  len(want),
+		MetadataMatch: bytes.Equal(gotMeta, wantMeta),
+		Mismatch:      -1,
+	}
+
+	library := len(got) > 20 && len(want) > 20 && got[0] == 0x73 && want[0] == 0x73
+	for i := 0; i < len(got) || i < len(want); i++ {
+		if library && i > 0 && i <= 20 {
+			continue
+		}
+
+		if i >= len(got) || i >= len(want) || got[i] != want[i] {
+			report.Mismatch = i
+			break
+		}
+	}
+
+	report.Match = report.Mismatch == -1
+	return report, nil
+}
+
+// stripMetadata splits code into its body and the CBOR metadata trailer
+// appended by solc, whose length is stored in the last two bytes.
+func stripMetadata(code []byte) ([]byte, []byte) {
+	if len(code) < 2 {
+		return code, nil
+	}
+
+	n := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	start := len(code) - n - 2
+	if start < 0 || code[start]&0xf0 != 0xa0 {
+		return code, nil
+	}
+
+	return code[:start], code[start:]
+}
+
+` + tmpSelectors + `// {{ ident "ToWei" }} parses a decimal amount of a unit with the given decimals,
+// such as 9 for gwei and 18 for ether, into wei.
+func {{ ident "ToWei" }}(s string, decimals int) (*big.Int, error) {
+{{- if ge .GoVersion 18 }}
+	whole, frac, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+{{- else }}
+	whole, frac := strings.TrimPrefix(s, "-"), ""
+	if i := strings.IndexByte(whole, '.'); i >= 0 {
+		whole, frac = whole[:i], whole[i+1:]
+	}
+{{- end }}
+	digits := whole + frac
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+
+	if len(frac) > decimals {
+		return nil, fmt.Errorf("amount %q has more than %d decimals", s, decimals)
+	}
+
+	v, _ := new(big.Int).SetString(digits+strings.Repeat("0", decimals-len(frac)), 10)
+	if strings.HasPrefix(s, "-") {
+		v.Neg(v)
+	}
+
+	return v, nil
+}
+
+// {{ ident "FromWei" }} formats an amount of wei as a decimal amount of a unit with
+// the given decimals.
+func {{ ident "FromWei" }}(wei *big.Int, decimals int) string {
+	s := new(big.Int).Abs(wei).String()
+	if d := decimals; d > 0 {
+		if len(s) <= d {
+			s = strings.Repeat("0", d-len(s)+1) + s
+		}
+		s = s[:len(s)-d] + "." + s[len(s)-d:]
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+
+	if wei.Sign() < 0 {
+		s = "-" + s
 	}
-	inputs, err := abis.Pack("{{ .Method }}"{{ .AbiPackParam }})
+
+	return s
+}
+
+// {{ ident "ParseEther" }} parses a decimal ether amount, such as "1.5", into wei.
+func {{ ident "ParseEther" }}(s string) (*big.Int, error) {
+	return {{ ident "ToWei" }}(s, 18)
+}
+
+{{ if .Decimals }}var (
+	decimalsOnce  sync.Once
+	decimalsValue int
+	decimalsErr   error
+)
+
+// tokenDecimals returns the token decimals, read from the contract once. A
+// failed read is returned again on every call.
+func tokenDecimals() (int, error) {
+	decimalsOnce.Do(func() {
+		v, err := {{ .Decimals }}()
+		if err != nil {
+			decimalsErr = err
+			return
+		}
+		decimalsValue = int(v{{ if .DecimalsBig }}.Int64(){{ end }})
+	})
+
+	return decimalsValue, decimalsErr
+}
+
+// {{ ident "FormatAmount" }} formats a raw token amount as a decimal string.
+func {{ ident "FormatAmount" }}(amount *big.Int) (string, error) {
+	d, err := tokenDecimals()
 	if err != nil {
-		panic(err)
+		return "", err
 	}
-	ret := exec(inputs)
-	res, err := abis.Unpack("{{ .Method }}", ret)
+
+	return {{ ident "FromWei" }}(amount, d), nil
+}
+
+// {{ ident "ParseAmount" }} parses a decimal string into a raw token amount.
+func {{ ident "ParseAmount" }}(s string) (*big.Int, error) {
+	d, err := tokenDecimals()
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	return {{ ident "ToWei" }}(s, d)
+}
+
+{{ end }}` + tmpTypeMaps
+
+var tmpResult = `// convertResult stores the i-th of the results into dst, a pointer to the Go
+// type it is bound to, converting it with abi.ConvertType. The values
+// abi.ConvertType cannot convert are reported as errors.
+func convertResult(res []{{ .Any }}, i int, dst {{ .Any }}) (err error) {
+	if i >= len(res) {
+		return fmt.Errorf("%w: missing result %d", {{ ident "ErrDecode" }}, i)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: result %d is %T, not %v: %v", {{ ident "ErrDecode" }}, i, res[i], reflect.TypeOf(dst).Elem(), r)
+		}
+	}()
+
+	v := reflect.ValueOf(abi.ConvertType(res[i], dst))
+	reflect.ValueOf(dst).Elem().Set(v.Elem())
+	return nil
+}
+{{ if ge .GoVersion 18 }}
+// result returns the i-th of the results as a T.
+func result[T any](res []any, i int) (T, error) {
+	var v T
+	err := convertResult(res, i, &v)
+	return v, err
+}
+{{ end }}
+`
+
+var tmpErrors = `var (
+{{- if not .CodecOnly }}
+	// {{ ident "ErrNoCode" }} is returned when there is no contract code to execute.
+	{{ ident "ErrNoCode" }} = errors.New("no contract code")
+	// {{ ident "ErrExecutionReverted" }} is returned when a call reverts.
+	{{ ident "ErrExecutionReverted" }} = errors.New("execution reverted")
+{{- end }}
+	// {{ ident "ErrDecode" }} is returned when return data or a log cannot be decoded.
+	{{ ident "ErrDecode" }} = errors.New("cannot decode")
+)
+
+// {{ ident "CallError" }} is the error of a failed method, naming the contract and
+// the method. Its Err wraps the error values above when they apply.
+type {{ ident "CallError" }} struct {
+	Contract string
+	Method   string
+	Err      error
+}
+
+func (e *{{ ident "CallError" }}) Error() string {
+	return e.Contract + "." + e.Method + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error the method failed with.
+func (e *{{ ident "CallError" }}) Unwrap() error {
+	return e.Err
+}
+
+// methodError wraps err as the error of method.
+func methodError(method string, err error) error {
+	return &{{ ident "CallError" }}{Contract: "{{ .Contract }}", Method: method, Err: err}
+}
+
+`
+
+var tmpSelectors = `// {{ ident "Selector" }} returns the selector of a function signature such as
+// "transfer(address,uint256)".
+func {{ ident "Selector" }}(sig string) [4]byte {
+	var sel [4]byte
+	copy(sel[:], crypto.Keccak256([]byte(sig)))
+	return sel
+}
+
+// {{ ident "FunctionPointer" }} composes the value of an external function type, the
+// address of the contract followed by the selector of the function.
+func {{ ident "FunctionPointer" }}(contract common.Address, sel [4]byte) [24]byte {
+	var fn [24]byte
+	copy(fn[:20], contract[:])
+	copy(fn[20:], sel[:])
+	return fn
+}
+
+// {{ ident "SplitFunctionPointer" }} decomposes the value of an external function type
+// into the address of the contract and the selector of the function.
+func {{ ident "SplitFunctionPointer" }}(fn [24]byte) (common.Address, [4]byte) {
+	var sel [4]byte
+	copy(sel[:], fn[20:])
+	return common.BytesToAddress(fn[:20]), sel
+}
+
+var (
+{{- range .Funcs }}
+	// {{ ident "Selector" }}{{ .Name }} is the selector of {{ .Sig }}.
+	{{ ident "Selector" }}{{ .Name }} = [4]byte{ {{- selectorBytes .Id -}} }
+{{- end }}
+)
+
+`
+
+var tmpTypeMaps = `{{ if .TypeMaps }}// Conversions between *big.Int and the types bound with --type-map. The methods
+// using those types fail while they are not set.
+var (
+{{- range .TypeMaps }}
+	// {{ ident .Shim }}FromBig converts a contract value into a {{ .Type }}.
+	{{ ident .Shim }}FromBig func(*big.Int) {{ .Type }}
+	// {{ ident .Shim }}ToBig converts a {{ .Type }} into a contract value.
+	{{ ident .Shim }}ToBig func({{ .Type }}) *big.Int
+{{- end }}
+)
+{{ range .TypeMaps }}
+func fromBig{{ .Shim }}(v *big.Int) (r {{ .Type }}, err error) {
+	if {{ ident .Shim }}FromBig == nil {
+		return r, errors.New("{{ ident .Shim }}FromBig is not set")
+	}
+
+	return {{ ident .Shim }}FromBig(v), nil
+}
+
+func toBig{{ .Shim }}(v {{ .Type }}) (*big.Int, error) {
+	if {{ ident .Shim }}ToBig == nil {
+		return nil, errors.New("{{ ident .Shim }}ToBig is not set")
+	}
+
+	return {{ ident .Shim }}ToBig(v), nil
+}
+{{ end }}
+{{ end }}`
+
+var tmpEvents = `// {{ ident "EventTopic" }} returns the topic of an event signature such as
+// "Transfer(address,address,uint256)".
+func {{ ident "EventTopic" }}(sig string) common.Hash {
+	return crypto.Keccak256Hash([]byte(sig))
+}
+
+// {{ ident "AddressTopic" }} encodes an indexed address as a topic.
+func {{ ident "AddressTopic" }}(v common.Address) common.Hash {
+	return common.BytesToHash(v.Bytes())
+}
+
+// {{ ident "IntTopic" }} encodes an indexed signed or unsigned integer as a topic.
+func {{ ident "IntTopic" }}(v *big.Int) common.Hash {
+	return common.BytesToHash(math.U256Bytes(new(big.Int).Set(v)))
+}
+
+// {{ ident "BoolTopic" }} encodes an indexed bool as a topic.
+func {{ ident "BoolTopic" }}(v bool) common.Hash {
+	var h common.Hash
+	if v {
+		h[31] = 1
 	}
-	return {{ .Return }}`
+	return h
+}
+
+// {{ ident "FixedBytesTopic" }} encodes an indexed bytes1 to bytes32 as a topic.
+func {{ ident "FixedBytesTopic" }}(v []byte) common.Hash {
+	var h common.Hash
+	copy(h[:], v)
+	return h
+}
+
+// {{ ident "DynamicTopic" }} returns the topic of an indexed string or bytes value,
+// which logs only hold as its hash.
+func {{ ident "DynamicTopic" }}(v []byte) common.Hash {
+	return crypto.Keccak256Hash(v)
+}
+
+// {{ ident "AddressFromTopic" }} decodes an indexed address from its topic.
+func {{ ident "AddressFromTopic" }}(h common.Hash) common.Address {
+	return common.BytesToAddress(h.Bytes())
+}
+
+// {{ ident "UintFromTopic" }} decodes an indexed unsigned integer from its topic.
+func {{ ident "UintFromTopic" }}(h common.Hash) *big.Int {
+	return new(big.Int).SetBytes(h.Bytes())
+}
+
+// {{ ident "IntFromTopic" }} decodes an indexed signed integer from its topic.
+func {{ ident "IntFromTopic" }}(h common.Hash) *big.Int {
+	return math.S256(new(big.Int).SetBytes(h.Bytes()))
+}
+
+// {{ ident "BoolFromTopic" }} decodes an indexed bool from its topic.
+func {{ ident "BoolFromTopic" }}(h common.Hash) bool {
+	return h != common.Hash{}
+}
+
+// {{ ident "FixedBytesFromTopic" }} decodes an indexed bytes1 to bytes32 of the given
+// size from its topic.
+func {{ ident "FixedBytesFromTopic" }}(h common.Hash, size int) []byte {
+	return common.CopyBytes(h[:size])
+}
+{{ if .Events }}
+var (
+{{- range .Events }}
+	// {{ ident "Topic" }}{{ .Name }} is the topic of {{ .Sig }}.
+{{ doc "\t" .Doc }}	{{ ident "Topic" }}{{ .Name }} = common.HexToHash("{{ .Topic }}")
+{{- end }}
+)
+{{ end }}
+`
+
+var tmpCaller = `{{range .Funcs}}// {{ ident .Name }} is a function represented contract method {{ .Id }}.
+{{ doc "" .Doc }}//
+// Solidity: {{ .Raw }}
+func {{ ident .Name }}({{$params := parseIn .Inputs}}{{ $params }}) {{$output := parseOut .Outputs}}{{ $output }} {
+	{{$body := parseBody .Method .Inputs .Outputs}}{{ $body }}
+}
+
+{{ end }}{{ if .Calls }}{{ range .Funcs }}{{ callType . }}
+{{ end }}{{ end }}`
+
+// TemplCodec is the template of the bindings generated with --codec-only,
+// which only pack and unpack calldata, return data and logs.
+var TemplCodec = `// Code generated by evmbind. DO NOT EDIT.
+package {{ .Package }}
+
+import (
+{{- if .Embed }}
+	_ "embed"
+{{- end }}
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+{{- if .EventHandler }}
+	"github.com/ethereum/go-ethereum/core/types"
+{{- end }}
+	"github.com/ethereum/go-ethereum/crypto"
+{{- range .TypeMaps }}
+	{{ .Package }} "{{ .Import }}"
+{{- end }}
+)
+
+var (
+	_ = big.NewInt
+	_ = time.Unix
+)
+
+{{ if .Embed }}// ABI is read from the ABI file next to the bindings.
+//
+//go:embed {{ .Embed }}.abi
+var ABI string
+{{ else }}var ABI = {{ .ABI }}
+{{ end }}
+// codec is the parsed ABI.
+var codec = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(ABI))
+	if err != nil {
+		panic(err)
+	}
+
+	return parsed
+}()
+
+` + tmpErrors + `// packInput packs the calldata of method with args. The panics of
+// abi.Pack, such as on nil *big.Int arguments, are reported as errors.
+func packInput(method string, args ...any) (data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			data, err = nil, methodError(method, fmt.Errorf("%v", r))
+		}
+	}()
+
+	data, err = codec.Pack(method, args...)
+	if err != nil {
+		return nil, methodError(method, err)
+	}
+
+	return data, nil
+}
+
+// unpackOutput unpacks the return data of method.
+func unpackOutput(method string, data []byte) ([]any, error) {
+	values, err := codec.Unpack(method, data)
+	if err != nil {
+		return nil, methodError(method, fmt.Errorf("%w: %v", {{ ident "ErrDecode" }}, err))
+	}
+
+	return values, nil
+}
+
+` + tmpResult + `// unpackEvent decodes the inputs of the named event, in their order, from
+// the topics and data of a log.
+func unpackEvent(name string, topics []common.Hash, data []byte) ([]any, error) {
+	event := codec.Events[name]
+	if !event.Anonymous {
+		if len(topics) == 0 || topics[0] != event.ID {
+			return nil, fmt.Errorf("%w: log is not a %s event", {{ ident "ErrDecode" }}, name)
+		}
+		topics = topics[1:]
+	}
+
+	unindexed, err := event.Inputs.NonIndexed().UnpackValues(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s event: %v", {{ ident "ErrDecode" }}, name, err)
+	}
+
+	values := make([]any, 0, len(event.Inputs))
+	for _, arg := range event.Inputs {
+		if !arg.Indexed {
+			values = append(values, unindexed[0])
+			unindexed = unindexed[1:]
+			continue
+		}
+
+		if len(topics) == 0 {
+			return nil, fmt.Errorf("%w: log has no topic for %s", {{ ident "ErrDecode" }}, arg.Name)
+		}
+
+		indexed := make(map[string]any)
+		if err := abi.ParseTopicsIntoMap(indexed, abi.Arguments{arg}, topics[:1]); err != nil {
+			return nil, fmt.Errorf("%w: %s event: %v", {{ ident "ErrDecode" }}, name, err)
+		}
+		values = append(values, indexed[arg.Name])
+		topics = topics[1:]
+	}
+
+	return values, nil
+}
+
+` + tmpSelectors + tmpTypeMaps + tmpEvents + `
+{{ range .Funcs }}{{ codecFunc . }}
+{{ end }}{{ range .Events }}{{ codecEvent . }}
+{{ end }}{{ with .EventHandler }}{{ codecDispatch . }}
+{{ end }}{{ if .Calls }}{{ range .Funcs }}{{ callType . }}
+{{ end }}{{ end }}`
+
+type tmpFnBodyData struct {
+	Method       string
+	// Convert holds the statements converting the arguments bound with
+	// --type-map ahead of the call.
+	Convert      string
+	AbiPackParam string
+	// Shims holds per result the type name of its --type-map conversion,
+	// empty when it is stored into rN as is.
+	Shims []string
+}
+
+// tmpFnBody is the function body of a method, converting each unpacked value
+// into its named result rN with convertResult.
+var tmpFnBody = `{{ with .Convert }}{{ . }}	{{ end }}{{ if not .Shims }}_, err = callResults("{{ .Method }}"{{ .AbiPackParam }})
+	return{{ else }}values, err := callResults("{{ .Method }}"{{ .AbiPackParam }})
+	if err != nil {
+		return
+	}
+{{- range $i, $shim := .Shims }}
+{{- if $shim }}
+	var v{{ $i }} *big.Int
+	if err = convertResult(values, {{ $i }}, &v{{ $i }}); err != nil {
+		err = methodError("{{ $.Method }}", err)
+		return
+	}
+	if r{{ $i }}, err = fromBig{{ $shim }}(v{{ $i }}); err != nil {
+		err = methodError("{{ $.Method }}", err)
+		return
+	}
+{{- else }}
+	if err = convertResult(values, {{ $i }}, &r{{ $i }}); err != nil {
+		err = methodError("{{ $.Method }}", err)
+		return
+	}
+{{- end }}
+{{- end }}
+	return{{ end }}`
+
+// CLIData is the data structure that is passed to the cli template.
+type CLIData struct {
+	// Package is the name of the bindings package.
+	Package string
+	// Import is the import path of the bindings package.
+	Import string
+	// Funcs is a list of functions.
+	Funcs []Function
+}
+
+var TemplCLI = `// Code generated by evmbind. DO NOT EDIT.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	{{ .Package }} "{{ .Import }}"
+)
+
+var (
+	_ = big.NewInt
+	_ = strconv.ParseInt
+	_ = hexutil.Encode
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <method> [flags]\n\nmethods:\n", os.Args[0])
+{{range .Funcs}}	fmt.Fprintln(os.Stderr, "  {{ .Method }}")
+{{end}}	os.Exit(2)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+` + tmpParseArgs + `
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+{{range .Funcs}}	case "{{ .Method }}":
+		fs := flag.NewFlagSet("{{ .Method }}", flag.ExitOnError)
+{{range $i, $in := .Inputs}}		flag{{ $i }} := fs.String("{{ cliFlag $in $i }}", "", "{{ $in.Type }}")
+{{end}}		fs.Parse(os.Args[2:])
+{{range $i, $in := .Inputs}}{{ cliParse $in $i }}{{end}}		{{ cliCall $.Package . }}
+{{end}}	default:
+		usage()
+	}
+}
+`
+
+// HTTPData is the data structure that is passed to the http template.
+type HTTPData struct {
+	// Package is the name of the bindings package.
+	Package string
+	// Any is the spelling of the empty interface for the target Go version.
+	Any string
+	// Funcs is a list of functions.
+	Funcs []Function
+}
+
+var TemplHTTP = `// Code generated by evmbind. DO NOT EDIT.
+package {{ .Package }}
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	_ = strconv.ParseInt
+)
+
+// {{ ident "Handler" }} returns an http.Handler serving every contract method at /<method>.
+// View and pure methods answer GET requests with their arguments in the query
+// string, other methods answer POST requests with a JSON object of arguments,
+// given either as strings or as JSON numbers and booleans. Results are
+// written as a JSON array, in the encoding described by the output schemas
+// of --with-schema.
+func {{ ident "Handler" }}() http.Handler {
+	mux := http.NewServeMux()
+{{range .Funcs}}	mux.HandleFunc("/{{ .Method }}", func(w http.ResponseWriter, r *http.Request) {
+		{{ if .Inputs }}args{{ else }}_{{ end }}, code, err := httpArgs(r, {{ if .Constant }}http.MethodGet{{ else }}http.MethodPost{{ end }})
+		if err != nil {
+			httpError(w, err, code)
+			return
+		}
+		defer httpRecover(w)
+{{range $i, $in := .Inputs}}{{ httpParse $in $i }}{{end}}		{{ httpCall . }}
+	})
+{{end}}	return mux
+}
+
+// httpArgs checks the request method and collects the call arguments.
+func httpArgs(r *http.Request, method string) (url.Values, int, error) {
+	if r.Method != method {
+		return nil, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method)
+	}
+
+	if method == http.MethodGet {
+		return r.URL.Query(), 0, nil
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	// Arguments are parsed from their string form, so both "5" and 5 are
+	// accepted, as are "true" and true.
+	args := make(url.Values)
+	for k, raw := range body {
+		var v string
+		switch raw[0] {
+		case '"':
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return nil, http.StatusBadRequest, err
+			}
+		case '{', '[', 'n':
+			return nil, http.StatusBadRequest, fmt.Errorf("argument %s: expected a string, number or boolean", k)
+		default:
+			v = string(raw)
+		}
+		args.Set(k, v)
+	}
+
+	return args, 0, nil
+}
+
+func httpError(w http.ResponseWriter, err error, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// httpRecover turns a panicking call into an internal server error.
+func httpRecover(w http.ResponseWriter) {
+	if e := recover(); e != nil {
+		httpError(w, fmt.Errorf("%v", e), http.StatusInternalServerError)
+	}
+}
+
+// httpReply writes the results of method as a JSON array.
+func httpReply(w http.ResponseWriter, method string, res []{{ .Any }}) {
+	abis, err := parsedABI()
+	if err != nil {
+		httpError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	out := make([]{{ .Any }}, len(res))
+	for i, v := range res {
+		out[i] = httpValue(abis.Methods[method].Outputs[i].Type, reflect.ValueOf(v))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
+
+// httpValue converts a result of the given ABI type into its JSON form.
+// Integers are decimal strings so that 256 bit values survive JSON number
+// precision, and byte values are 0x-prefixed hex strings.
+func httpValue(kind abi.Type, v reflect.Value) {{ .Any }} {
+	switch kind.T {
+	case abi.IntTy, abi.UintTy:
+		if b, ok := v.Interface().(*big.Int); ok {
+			return b.String()
+		}
+		if kind.T == abi.IntTy {
+			return strconv.FormatInt(v.Int(), 10)
+		}
+		return strconv.FormatUint(v.Uint(), 10)
+	case abi.BytesTy:
+		return hexutil.Encode(v.Bytes())
+	case abi.FixedBytesTy, abi.FunctionTy:
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return hexutil.Encode(b)
+	case abi.SliceTy, abi.ArrayTy:
+		out := make([]{{ .Any }}, v.Len())
+		for i := range out {
+			out[i] = httpValue(*kind.Elem, v.Index(i))
+		}
+		return out
+	}
+
+	return v.Interface()
+}
+
+` + tmpParseArgs + `
+`
+
+// tmpParseArgs holds the helpers used by generated code to read arguments
+// from strings.
+var tmpParseArgs = `func parseBig(s string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", s)
+	}
+	return v, nil
+}
+
+func parseAddress(s string) (common.Address, error) {
+	if !common.IsHexAddress(s) {
+		return common.Address{}, fmt.Errorf("invalid address %q", s)
+	}
+	return common.HexToAddress(s), nil
+}
+
+func parseFixedBytes(s string, n int) ([]byte, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != n {
+		return nil, fmt.Errorf("invalid length %d for bytes%d", len(b), n)
+	}
+	return b, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(v, 0), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}`
+
+// StorageData is the data structure that is passed to the storage template.
+type StorageData struct {
+	// Package is the name of the bindings package.
+	Package string
+	// Unexported reports whether the accessors are unexported.
+	Unexported bool
+	// Vars is a list of state variables.
+	Vars []StorageVar
+}
+
+// StorageVar is a state variable of the storage layout.
+type StorageVar struct {
+	// Name is the Go name of the variable.
+	Name string
+	// Label is the Solidity name of the variable.
+	Label string
+	// Type is the Solidity type of the variable.
+	Type string
+	// Slot is the hex encoded slot of the variable.
+	Slot string
+	// Offset is the byte offset of the variable inside its slot.
+	Offset int
+	// Params are the accessor parameters for mapping keys and array indexes.
+	Params string
+	// Args are the names of the accessor parameters.
+	Args string
+	// Path is the slot math applied for each mapping or array level.
+	Path []string
+	// GoType is the Go type of the value, empty if it cannot be read as a
+	// single word.
+	GoType string
+	// Size is the size of the value in bytes.
+	Size int
+	// Decode is the expression decoding the value bytes b.
+	Decode string
+}
+
+var TemplStorage = `// Code generated by evmbind. DO NOT EDIT.
+package {{ .Package }}
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// {{ ident "StorageReader" }} reads contract storage. It is implemented by ethclient.Client.
+type {{ ident "StorageReader" }} interface {
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+}
+
+// {{ ident "MappingSlot" }} returns the slot of the value stored under the
+// encoded key of the mapping at slot, keccak256(key . slot). Value type keys
+// are encoded with {{ ident "AddressKey" }}, {{ ident "IntKey" }}, {{ ident "BoolKey" }} or
+// {{ ident "FixedBytesKey" }}, string and bytes keys are used as they are.
+func {{ ident "MappingSlot" }}(key []byte, slot common.Hash) common.Hash {
+	return crypto.Keccak256Hash(key, slot[:])
+}
+
+// {{ ident "AddressKey" }} encodes an address mapping key.
+func {{ ident "AddressKey" }}(v common.Address) []byte {
+	return common.LeftPadBytes(v.Bytes(), 32)
+}
+
+// {{ ident "IntKey" }} encodes a signed or unsigned integer mapping key.
+func {{ ident "IntKey" }}(v *big.Int) []byte {
+	return math.U256Bytes(new(big.Int).Set(v))
+}
+
+// {{ ident "BoolKey" }} encodes a bool mapping key.
+func {{ ident "BoolKey" }}(v bool) []byte {
+	word := make([]byte, 32)
+	if v {
+		word[31] = 1
+	}
+	return word
+}
+
+// {{ ident "FixedBytesKey" }} encodes a bytes1 to bytes32 mapping key.
+func {{ ident "FixedBytesKey" }}(v []byte) []byte {
+	return common.RightPadBytes(v, 32)
+}
+
+// {{ ident "ArraySlot" }} returns the slot and byte offset of the element at index of the
+// dynamic array at slot, whose elements are size bytes long.
+func {{ ident "ArraySlot" }}(slot common.Hash, index uint64, size int) (common.Hash, int) {
+	return {{ ident "StaticArraySlot" }}(crypto.Keccak256Hash(slot[:]), index, size)
+}
+
+// {{ ident "StaticArraySlot" }} returns the slot and byte offset of the element at index of
+// the fixed size array starting at slot, whose elements are size bytes long.
+// Elements of up to 16 bytes are packed into shared slots, larger ones start
+// a new slot each.
+func {{ ident "StaticArraySlot" }}(slot common.Hash, index uint64, size int) (common.Hash, int) {
+	if size > 16 {
+		n := uint64((size + 31) / 32)
+		return {{ ident "OffsetSlot" }}(slot, new(big.Int).Mul(new(big.Int).SetUint64(n), new(big.Int).SetUint64(index))), 0
+	}
+
+	per := uint64(32 / size)
+	return {{ ident "OffsetSlot" }}(slot, new(big.Int).SetUint64(index/per)), int(index%per) * size
+}
+
+// {{ ident "OffsetSlot" }} returns the slot n slots after slot, as used for struct members
+// whose layout slot is relative to the start of the struct.
+func {{ ident "OffsetSlot" }}(slot common.Hash, n *big.Int) common.Hash {
+	v := new(big.Int).Add(slot.Big(), n)
+	return common.BigToHash(math.U256(v))
+}
+
+// {{ ident "StorageValue" }} extracts the size bytes at byte offset off from the low order
+// end of a storage word.
+func {{ ident "StorageValue" }}(word []byte, off, size int) []byte {
+	word = common.LeftPadBytes(word, 32)
+	return word[32-off-size : 32-off]
+}
+
+// signedWord decodes b as a two's complement integer.
+func signedWord(b []byte) *big.Int {
+	v := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+	}
+	return v
+}
+{{range .Vars}}
+// {{ ident "StorageSlot" }}{{ .Name }} returns the storage slot of {{ .Label }}.
+//
+// Solidity: {{ .Type }} {{ .Label }}
+func {{ ident "StorageSlot" }}{{ .Name }}({{ .Params }}) common.Hash {
+	slot, _ := storage{{ .Name }}({{ .Args }})
+	return slot
+}
+
+// storage{{ .Name }} returns the slot and byte offset of {{ .Label }}.
+func storage{{ .Name }}({{ .Params }}) (common.Hash, int) {
+	slot, off := common.HexToHash("{{ .Slot }}"), {{ .Offset }}
+{{range .Path}}	{{ . }}
+{{end}}	return slot, off
+}
+{{ if .GoType }}
+// {{ ident "Read" }}{{ .Name }}At reads {{ .Label }} from the storage of contract at block,
+// or at the latest block if block is nil.
+func {{ ident "Read" }}{{ .Name }}At(ctx context.Context, backend {{ ident "StorageReader" }}, contract common.Address, block *big.Int{{ if .Params }}, {{ .Params }}{{ end }}) (v {{ .GoType }}, err error) {
+	slot, off := storage{{ .Name }}({{ .Args }})
+	word, err := backend.StorageAt(ctx, contract, slot, block)
+	if err != nil {
+		return v, err
+	}
+
+	b := {{ ident "StorageValue" }}(word, off, {{ .Size }})
+	return {{ .Decode }}, nil
+}
+{{ end }}{{ end }}`
+
+// PackedData is the data structure that is passed to the packed template.
+type PackedData struct {
+	// Package is the name of the bindings package.
+	Package string
+	// Unexported reports whether the helpers are unexported.
+	Unexported bool
+	// Helpers is a list of packed encoding helpers.
+	Helpers []PackedHelper
+}
+
+// PackedHelper is a packed encoding helper.
+type PackedHelper struct {
+	// Name is the name of the helper.
+	Name string
+	// Sig is the Solidity expression the helper mirrors.
+	Sig string
+	// Params are the helper parameters.
+	Params string
+	// Parts are the expressions encoding each parameter.
+	Parts []string
+}
+
+var TemplPacked = `// Code generated by evmbind. DO NOT EDIT.
+package {{ .Package }}
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+var (
+	_ = common.Address{}
+)
+
+// packedInt encodes v as a size byte two's complement integer.
+func packedInt(v *big.Int, size int) []byte {
+	return math.U256Bytes(new(big.Int).Set(v))[32-size:]
+}
+
+func packedBool(v bool) []byte {
+	if v {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+{{range .Helpers}}
+// {{ ident "Pack" }}{{ .Name }}Packed encodes its arguments without padding, the way
+// Solidity does. Hash the result with crypto.Keccak256Hash to build digests.
+//
+// Solidity: {{ .Sig }}
+func {{ ident "Pack" }}{{ .Name }}Packed({{ .Params }}) []byte {
+	return bytes.Join([][]byte{
+{{range .Parts}}		{{ . }},
+{{end}}	}, nil)
+}
+{{end}}`
+
+// AddressesData is the data structure that is passed to the addresses
+// template.
+type AddressesData struct {
+	// Package is the name of the bindings package.
+	Package string
+	// Unexported reports whether the declarations are unexported.
+	Unexported bool
+	// Contract is the name of the contract.
+	Contract string
+	// Deployments is a list of deployments, ordered by chain ID.
+	Deployments []Deployment
+	// Metadata reports whether any deployment carries transaction details.
+	Metadata bool
+}
+
+// Deployment is a deployment of the contract on a chain.
+type Deployment struct {
+	// ChainID is the ID of the chain.
+	ChainID uint64
+	// Address is the checksummed address of the contract.
+	Address string
+	// Network is the name of the network, if known.
+	Network string
+	// TxHash is the hash of the deploying transaction, if known.
+	TxHash string
+	// Block is the number of the block including TxHash, if known.
+	Block uint64
+}
+
+var TemplAddresses = `// Code generated by evmbind. DO NOT EDIT.
+package {{ .Package }}
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// {{ ident "Addresses" }} maps chain IDs to the addresses {{ .Contract }} is deployed at.
+var {{ ident "Addresses" }} = map[uint64]common.Address{
+{{range .Deployments}}	{{ .ChainID }}: common.HexToAddress("{{ .Address }}"),{{ if .Network }} // {{ .Network }}{{ end }}
+{{end}}}
+
+// {{ ident "AddressForChain" }} returns the address {{ .Contract }} is deployed at on
+// chainID.
+func {{ ident "AddressForChain" }}(chainID uint64) (common.Address, error) {
+	addr, ok := {{ ident "Addresses" }}[chainID]
+	if !ok {
+		return common.Address{}, fmt.Errorf("{{ .Contract }} is not deployed on chain %d", chainID)
+	}
+
+	return addr, nil
+}
+{{ if .Metadata }}
+// {{ ident "DeploymentInfo" }} describes the transaction that deployed a contract.
+type {{ ident "DeploymentInfo" }} struct {
+	Address common.Address
+	TxHash  common.Hash
+	// Block is the block number including TxHash, zero if unknown.
+	Block uint64
+}
+
+// {{ ident "Deployments" }} maps chain IDs to the transactions that deployed {{ .Contract }},
+// for the chains where they are known.
+var {{ ident "Deployments" }} = map[uint64]{{ ident "DeploymentInfo" }}{
+{{range .Deployments}}{{ if .TxHash }}	{{ .ChainID }}: {
+		Address: common.HexToAddress("{{ .Address }}"),
+		TxHash:  common.HexToHash("{{ .TxHash }}"),
+		Block:   {{ .Block }},
+	},
+{{ end }}{{end}}}
+{{ end }}`
+
+// SrcMapData is the data structure that is passed to the srcmap template.
+type SrcMapData struct {
+	// Package is the name of the bindings package.
+	Package string
+	// Unexported reports whether the declarations are unexported.
+	Unexported bool
+	// Files is a list of the source files, by source index.
+	Files []string
+	// Lines is the line table of the runtime code, ordered by offset.
+	Lines []SrcLine
+}
+
+// SrcLine is a row of the line table, covering the code from PC up to the
+// next row.
+type SrcLine struct {
+	// PC is the offset of the first instruction in the runtime code.
+	PC int
+	// File is the source index, -1 when the code has no source.
+	File int
+	// Line is the 1-based line in File.
+	Line int
+}
+
+var TemplSrcMap = `// Code generated by evmbind. DO NOT EDIT.
+package {{ .Package }}
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// {{ ident "SourceFiles" }} lists the files the runtime code was compiled from, by source
+// index.
+var {{ ident "SourceFiles" }} = []string{
+{{range .Files}}	"{{ . }}",
+{{end}}}
+
+// sourceLines is the line table of the runtime code, ordered by offset. A
+// row covers the code up to the next one and a file of -1 has no source.
+var sourceLines = []struct {
+	pc, file, line int
+}{
+{{range .Lines}}	{ {{- .PC }}, {{ .File }}, {{ .Line -}} },
+{{end}}}
+
+// {{ ident "ResolvePC" }} returns the source file and line the instruction at pc of the
+// runtime code was compiled from. It returns an empty file and a zero line
+// for code generated by the compiler and offsets outside the code.
+func {{ ident "ResolvePC" }}(pc uint64) (file string, line int) {
+	i := sourceRow(pc)
+	if i < 0 {
+		return "", 0
+	}
+
+	return {{ ident "SourceFiles" }}[sourceLines[i].file], sourceLines[i].line
+}
+
+// sourceRow returns the index of the sourceLines row covering pc, or -1 when
+// the code at pc has no source.
+func sourceRow(pc uint64) int {
+	i := sort.Search(len(sourceLines), func(i int) bool {
+		return uint64(sourceLines[i].pc) > pc
+	}) - 1
+	if i < 0 || sourceLines[i].file < 0 {
+		return -1
+	}
+
+	return i
+}
+
+var (
+	coverageMu sync.Mutex
+	// coverage counts the executions of the runtime code instructions by
+	// offset. It is nil while coverage is not enabled.
+	coverage map[uint64]int
+)
+
+// {{ ident "EnableCoverage" }} starts recording the instructions of the contract code the
+// calls execute for {{ ident "WriteCoverage" }}, discarding an earlier record.
+func {{ ident "EnableCoverage" }}() {
+	coverageMu.Lock()
+	defer coverageMu.Unlock()
+
+	coverage = make(map[uint64]int)
+}
+
+// {{ ident "WriteCoverage" }} writes the source lines the recorded calls executed to w as
+// an LCOV tracefile, which genhtml renders into an HTML report. A line counts
+// the executions of its most executed instruction.
+func {{ ident "WriteCoverage" }}(w io.Writer) error {
+	coverageMu.Lock()
+	defer coverageMu.Unlock()
+
+	hits := make([]map[int]int, len({{ ident "SourceFiles" }}))
+	for i := range hits {
+		hits[i] = make(map[int]int)
+	}
+	for _, row := range sourceLines {
+		if row.file >= 0 {
+			hits[row.file][row.line] = 0
+		}
+	}
+	for pc, n := range coverage {
+		if i := sourceRow(pc); i >= 0 {
+			row := sourceLines[i]
+			if n > hits[row.file][row.line] {
+				hits[row.file][row.line] = n
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	for file, counts := range hits {
+		if len(counts) == 0 {
+			continue
+		}
+
+		lines := make([]int, 0, len(counts))
+		for line := range counts {
+			lines = append(lines, line)
+		}
+		sort.Ints(lines)
+
+		fmt.Fprintf(&b, "SF:%s\n", {{ ident "SourceFiles" }}[file])
+		hit := 0
+		for _, line := range lines {
+			fmt.Fprintf(&b, "DA:%d,%d\n", line, counts[line])
+			if counts[line] > 0 {
+				hit++
+			}
+		}
+		fmt.Fprintf(&b, "LF:%d\nLH:%d\nend_of_record\n", len(lines), hit)
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// coverageConfig returns the EVM configuration of the calls, tracing them
+// while coverage is enabled.
+func coverageConfig() vm.Config {
+	coverageMu.Lock()
+	defer coverageMu.Unlock()
+
+	return vm.Config{Debug: coverage != nil, Tracer: coverageTracer{}}
+}
+
+// coverageTracer records the instructions of the contract code the calls
+// execute, at any call depth.
+type coverageTracer struct{}
+
+func (coverageTracer) CaptureState(pc uint64, _ vm.OpCode, _, _ uint64, scope *vm.ScopeContext, _ []byte, _ int, _ error) {
+	if addr := scope.Contract.CodeAddr; addr == nil || *addr != {{ ident "ContractAddress" }} {
+		return
+	}
+
+	coverageMu.Lock()
+	defer coverageMu.Unlock()
+
+	if coverage != nil {
+		coverage[pc]++
+	}
+}
+
+func (coverageTracer) CaptureTxStart(uint64) {}
+
+func (coverageTracer) CaptureTxEnd(uint64) {}
+
+func (coverageTracer) CaptureStart(*vm.EVM, common.Address, common.Address, bool, []byte, uint64, *big.Int) {
+}
+
+func (coverageTracer) CaptureEnd([]byte, uint64, time.Duration, error) {}
+
+func (coverageTracer) CaptureEnter(vm.OpCode, common.Address, common.Address, []byte, uint64, *big.Int) {
+}
+
+func (coverageTracer) CaptureExit([]byte, uint64, error) {}
 
+func (coverageTracer) CaptureFault(uint64, vm.OpCode, uint64, uint64, *vm.ScopeContext, int, error) {}
+`
@@ -0,0 +1,156 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// typeMapper resolves the Go types given with --type-map. Mappings are keyed
+// by ABI type, such as uint256, or by method and parameter, such as
+// transfer.amount, the latter taking precedence.
+type typeMapper struct {
+	maps map[string]*TypeMap
+	used map[string]bool
+}
+
+// parseTypeMaps parses key=import/path.Type mappings. The type may be a
+// pointer, such as *github.com/holiman/uint256.Int.
+func parseTypeMaps(specs []string) (*typeMapper, error) {
+	m := &typeMapper{maps: make(map[string]*TypeMap), used: make(map[string]bool)}
+	shims := make(map[string]string)
+	for _, spec := range specs {
+		key, typ, ok := strings.Cut(spec, "=")
+		key, typ = strings.TrimSpace(key), strings.TrimSpace(typ)
+		ptr := strings.HasPrefix(typ, "*")
+		typ = strings.TrimPrefix(typ, "*")
+
+		dot := strings.LastIndex(typ, ".")
+		if !ok || key == "" || dot <= 0 || strings.LastIndex(typ, "/") > dot {
+			return nil, fmt.Errorf("type-map: %q is not of the form key=import/path.Type", spec)
+		}
+
+		imp, name := typ[:dot], typ[dot+1:]
+		pkg := path.Base(imp)
+		if !token.IsIdentifier(pkg) || !token.IsIdentifier(name) || !token.IsExported(name) {
+			return nil, fmt.Errorf("type-map: %q does not name an exported type of an importable package", typ)
+		}
+
+		if reservedImport(pkg) {
+			return nil, fmt.Errorf("type-map: package name %s of %s clashes with an import of the bindings", pkg, imp)
+		}
+
+		if _, ok := m.maps[key]; ok {
+			return nil, fmt.Errorf("type-map: %s is mapped more than once", key)
+		}
+
+		goType := pkg + "." + name
+		if ptr {
+			goType = "*" + goType
+		}
+
+		// The shims are named after the type, which must therefore be
+		// unique across packages.
+		if prev, ok := shims[name]; ok && prev != goType {
+			return nil, fmt.Errorf("type-map: %s and %s share the type name %s", prev, goType, name)
+		}
+		shims[name] = goType
+
+		m.maps[key] = &TypeMap{Import: imp, Package: pkg, Type: goType, Shim: name}
+	}
+
+	return m, nil
+}
+
+// reservedImport reports whether pkg is the name of a package imported by
+// the generated bindings.
+func reservedImport(pkg string) bool {
+	switch pkg {
+	case "bytes", "context", "fmt", "big", "strings", "sync", "time", "abi", "common", "runtime", "crypto",
+		"math", "gzip", "base64", "io", "errors", "vm", "reflect", "state", "rawdb", "types":
+		return true
+	}
+
+	return false
+}
+
+// lookup returns the mapping of the named parameter of method, or nil if its
+// type is kept.
+func (m *typeMapper) lookup(method, param string, kind abi.Type) (*TypeMap, error) {
+	key := kind.String()
+	if _, ok := m.maps[method+"."+param]; ok && param != "" {
+		key = method + "." + param
+	}
+
+	tm, ok := m.maps[key]
+	if !ok {
+		return nil, nil
+	}
+	m.used[key] = true
+
+	if bindType(kind) != "*big.Int" {
+		return nil, fmt.Errorf("type-map: %s of %s is %s, which is not bound to *big.Int", param, method, kind)
+	}
+
+	return tm, nil
+}
+
+// check reports parameter mappings that matched no parameter.
+func (m *typeMapper) check() error {
+	var unknown []string
+	for key := range m.maps {
+		if strings.Contains(key, ".") && !m.used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("type-map: no parameter named %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// typeMaps returns the mappings used by funcs, ordered by type.
+func typeMaps(funcs []Function) []TypeMap {
+	seen := make(map[string]bool)
+
+	var maps []TypeMap
+	for _, fn := range funcs {
+		for _, arg := range append(append([]Argument{}, fn.Inputs...), fn.Outputs...) {
+			if arg.Map != nil && !seen[arg.Map.Type] {
+				seen[arg.Map.Type] = true
+				maps = append(maps, *arg.Map)
+			}
+		}
+	}
+
+	sort.Slice(maps, func(i, j int) bool {
+		return maps[i].Type < maps[j].Type
+	})
+
+	return maps
+}
@@ -0,0 +1,166 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/urfave/cli/v2"
+)
+
+// storageGap is the conventional name of reserved storage arrays.
+const storageGap = "__gap"
+
+// storageItem is a state variable placed in storage.
+type storageItem struct {
+	contract string
+	label    string
+	typ      string
+	size     int
+	// start and end are the first and last+1 byte positions in storage.
+	start, end *big.Int
+}
+
+func (s storageItem) String() string {
+	return fmt.Sprintf("%s %s.%s", s.typ, s.contract, s.label)
+}
+
+func (s storageItem) overlaps(o storageItem) bool {
+	return s.start.Cmp(o.end) < 0 && o.start.Cmp(s.end) < 0
+}
+
+// storageItems places every variable of the layout at its byte range.
+func storageItems(layout *storageLayout) ([]storageItem, error) {
+	var items []storageItem
+	for _, v := range layout.Storage {
+		slot, ok := new(big.Int).SetString(v.Slot, 10)
+		if !ok {
+			return nil, fmt.Errorf("storage-layout: invalid slot %q of %s", v.Slot, v.Label)
+		}
+
+		t := layout.Types[v.Type]
+		start := new(big.Int).Mul(slot, big.NewInt(32))
+		start.Add(start, big.NewInt(int64(v.Offset)))
+		items = append(items, storageItem{
+			contract: v.Contract,
+			label:    v.Label,
+			typ:      t.Label,
+			size:     t.size(),
+			start:    start,
+			end:      new(big.Int).Add(start, big.NewInt(int64(t.size()))),
+		})
+	}
+
+	return items, nil
+}
+
+// upgradeCheck compares two storage layouts of an upgradeable contract and
+// fails when the new one would corrupt storage written by the old one.
+func upgradeCheck(ctx *cli.Context) error {
+	var layouts [2][]storageItem
+	for i, name := range []string{"old-layout", "new-layout"} {
+		layout, err := readStorageLayout(ctx.Path(name))
+		if err != nil {
+			return err
+		}
+
+		layouts[i], err = storageItems(layout)
+		if err != nil {
+			return err
+		}
+	}
+
+	problems := checkUpgrade(layouts[0], layouts[1])
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+
+	if len(problems) > 0 {
+		return cli.Exit(fmt.Sprintf("%d storage layout problem(s)", len(problems)), 1)
+	}
+
+	return nil
+}
+
+// checkUpgrade applies the usual upgrade safety rules: existing variables keep
+// their position, type and name, new variables are only appended or take the
+// place of a shrinking gap, and gaps keep ending at the same slot.
+func checkUpgrade(old, new []storageItem) []string {
+	key := func(s storageItem) string { return s.contract + "." + s.label }
+
+	newByKey := make(map[string]storageItem)
+	for _, n := range new {
+		newByKey[key(n)] = n
+	}
+
+	oldByKey := make(map[string]storageItem)
+	var problems []string
+	for _, o := range old {
+		oldByKey[key(o)] = o
+		n, ok := newByKey[key(o)]
+
+		switch {
+		case !ok && o.label == storageGap:
+			problems = append(problems, fmt.Sprintf("gap removed: %s", o))
+		case !ok:
+			if r, found := itemAt(new, o.start); found && r.typ == o.typ && r.size == o.size {
+				problems = append(problems, fmt.Sprintf("renamed: %s is now %s", o, r.label))
+				continue
+			}
+			problems = append(problems, fmt.Sprintf("removed: %s", o))
+		case o.label == storageGap:
+			if n.start.Cmp(o.start) < 0 || n.end.Cmp(o.end) != 0 {
+				problems = append(problems, fmt.Sprintf("gap misuse: %s must end at byte %v, ends at %v", o, o.end, n.end))
+			}
+		case n.start.Cmp(o.start) != 0:
+			problems = append(problems, fmt.Sprintf("moved: %s from byte %v to %v", o, o.start, n.start))
+		case n.typ != o.typ || n.size != o.size:
+			problems = append(problems, fmt.Sprintf("type changed: %s is now %s", o, n.typ))
+		}
+	}
+
+	for _, n := range new {
+		if _, ok := oldByKey[key(n)]; ok {
+			continue
+		}
+
+		for _, o := range old {
+			if o.label != storageGap && n.overlaps(o) {
+				if _, kept := newByKey[key(o)]; kept {
+					problems = append(problems, fmt.Sprintf("inserted: %s overlaps %s", n, o))
+				}
+				break
+			}
+		}
+	}
+
+	return problems
+}
+
+// itemAt returns the variable starting at byte position pos.
+func itemAt(items []storageItem, pos *big.Int) (storageItem, bool) {
+	for _, s := range items {
+		if s.start.Cmp(pos) == 0 && s.label != storageGap {
+			return s, true
+		}
+	}
+
+	return storageItem{}, false
+}
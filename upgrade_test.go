@@ -0,0 +1,149 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestCheckUpgrade(t *testing.T) {
+	// item places a variable of C at slot and offset.
+	item := func(label, typ string, slot, offset, size int) storageItem {
+		start := big.NewInt(int64(slot*32 + offset))
+		return storageItem{
+			contract: "C",
+			label:    label,
+			typ:      typ,
+			size:     size,
+			start:    start,
+			end:      new(big.Int).Add(start, big.NewInt(int64(size))),
+		}
+	}
+	a := item("a", "uint256", 0, 0, 32)
+	b := item("b", "address", 1, 0, 20)
+	gap := item(storageGap, "uint256[49]", 1, 0, 49*32)
+
+	tests := []struct {
+		name     string
+		old, new []storageItem
+		want     []string
+	}{
+		{
+			name: "unchanged",
+			old:  []storageItem{a, b},
+			new:  []storageItem{a, b},
+		},
+		{
+			name: "appended",
+			old:  []storageItem{a},
+			new:  []storageItem{a, b},
+		},
+		{
+			name: "packed into a free slot end",
+			old:  []storageItem{a, b},
+			new:  []storageItem{a, b, item("c", "bool", 1, 20, 1)},
+		},
+		{
+			name: "removed",
+			old:  []storageItem{a, b},
+			new:  []storageItem{a},
+			want: []string{"removed: address C.b"},
+		},
+		{
+			name: "renamed",
+			old:  []storageItem{a},
+			new:  []storageItem{item("total", "uint256", 0, 0, 32)},
+			want: []string{"renamed: uint256 C.a is now total"},
+		},
+		{
+			name: "type changed",
+			old:  []storageItem{a},
+			new:  []storageItem{item("a", "address", 0, 0, 20)},
+			want: []string{"type changed: uint256 C.a is now address"},
+		},
+		{
+			name: "inserted",
+			old:  []storageItem{a},
+			new:  []storageItem{item("c", "uint256", 0, 0, 32), item("a", "uint256", 1, 0, 32)},
+			want: []string{"moved: uint256 C.a from byte 0 to 32", "inserted: uint256 C.c overlaps uint256 C.a"},
+		},
+		{
+			name: "gap shrinks",
+			old:  []storageItem{a, gap},
+			new:  []storageItem{a, b, item(storageGap, "uint256[48]", 2, 0, 48*32)},
+		},
+		{
+			name: "gap moves its end",
+			old:  []storageItem{a, gap},
+			new:  []storageItem{a, b, item(storageGap, "uint256[49]", 2, 0, 49*32)},
+			want: []string{"gap misuse: uint256[49] C.__gap must end at byte 1600, ends at 1632"},
+		},
+		{
+			name: "gap removed",
+			old:  []storageItem{a, gap},
+			new:  []storageItem{a},
+			want: []string{"gap removed: uint256[49] C.__gap"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := checkUpgrade(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: checkUpgrade = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStorageItems(t *testing.T) {
+	layout := &storageLayout{Types: map[string]storageType{
+		"t_uint256": {Encoding: "inplace", Label: "uint256", NumberOfBytes: "32"},
+		"t_address": {Encoding: "inplace", Label: "address", NumberOfBytes: "20"},
+		"t_bool":    {Encoding: "inplace", Label: "bool", NumberOfBytes: "1"},
+	}}
+	for _, v := range []struct {
+		label, slot, id string
+		offset          int
+	}{{"a", "0", "t_uint256", 0}, {"owner", "1", "t_address", 0}, {"paused", "1", "t_bool", 20}, {"far", "1000000000000000000000", "t_uint256", 0}} {
+		layout.Storage = append(layout.Storage, struct {
+			Contract string `json:"contract"`
+			Label    string `json:"label"`
+			Offset   int    `json:"offset"`
+			Slot     string `json:"slot"`
+			Type     string `json:"type"`
+		}{"C", v.label, v.offset, v.slot, v.id})
+	}
+
+	items, err := storageItems(layout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][2]string{{"0", "32"}, {"32", "52"}, {"52", "53"}, {"32000000000000000000000", "32000000000000000000032"}}
+	for i, s := range items {
+		if got := [2]string{s.start.String(), s.end.String()}; got != want[i] {
+			t.Errorf("%s: placed at %v, want %v", s.label, got, want[i])
+		}
+	}
+
+	layout.Storage[0].Slot = "0x0"
+	if _, err := storageItems(layout); err == nil {
+		t.Error("storageItems accepted the hex slot 0x0")
+	}
+}
@@ -0,0 +1,118 @@
+// This file is part of evmbind.
+
+// Copyright (C) 2022 Ade M Ramdani.
+// SPDX-License-Identifier: GPL-3.0-or-later
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/urfave/cli/v2"
+)
+
+// buildInfo identifies an evmbind binary.
+type buildInfo struct {
+	// Version is the module version, or "(devel)" for local builds.
+	Version string
+	// Commit is the VCS revision the binary was built from, if known.
+	Commit string
+	// Modified reports whether the working tree had local changes.
+	Modified bool
+	// Geth is the go-ethereum version evmbind was built against, if known.
+	Geth string
+	// Go is the version of the Go toolchain.
+	Go string
+}
+
+// readBuildInfo reads the build information embedded in the binary.
+func readBuildInfo() buildInfo {
+	b := buildInfo{Version: "(devel)"}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+
+	b.Go = info.GoVersion
+	if info.Main.Version != "" {
+		b.Version = info.Main.Version
+	}
+
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			b.Commit = s.Value
+		case "vcs.modified":
+			b.Modified = s.Value == "true"
+		}
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path != gethModule {
+			continue
+		}
+
+		b.Geth = dep.Version
+		if dep.Replace != nil && dep.Replace.Version != "" {
+			b.Geth = dep.Replace.Version
+		}
+	}
+
+	return b
+}
+
+// String returns the version, followed by the short commit for local
+// builds, such as "v1.2.0" or "(devel) 1a2b3c4d5e6f-dirty".
+func (b buildInfo) String() string {
+	if b.Commit == "" || b.Version != "(devel)" {
+		return b.Version
+	}
+
+	return b.Version + " " + b.commit()
+}
+
+// commit returns the short commit, marked dirty for modified trees.
+func (b buildInfo) commit() string {
+	commit := b.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	if b.Modified {
+		commit += "-dirty"
+	}
+
+	return commit
+}
+
+// versioner prints the build information of evmbind.
+func versioner(ctx *cli.Context) error {
+	b := readBuildInfo()
+
+	fmt.Println("evmbind", b.Version)
+	if b.Commit != "" {
+		fmt.Println("commit:", b.commit())
+	}
+	if b.Geth != "" {
+		fmt.Println("go-ethereum:", b.Geth)
+	}
+	if b.Go != "" {
+		fmt.Println("go:", b.Go)
+	}
+
+	return nil
+}